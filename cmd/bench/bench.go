@@ -0,0 +1,88 @@
+package bench
+
+import (
+	"dgram/cmd/transcribe"
+	"dgram/lib/config"
+	"fmt"
+	"os"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/andrerfcsantos/deepgram-go-captions/converters"
+	"github.com/andrerfcsantos/deepgram-go-captions/renderers"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cfg *config.Config
+)
+
+// benchmarks are the renderers of the captions library that bench times.
+var benchmarks = []struct {
+	name   string
+	render func(converters.Converter) (string, error)
+}{
+	{"SRT", renderers.SRT},
+	{"WebVTT", renderers.WebVTT},
+}
+
+var benchCmd = &cobra.Command{
+	Use:    "bench <file>",
+	Short:  "time how long the captions library takes to render the cached transcript of a file",
+	Long:   "Render the cached transcript of a file as SRT and WebVTT over and over again, printing the time and the allocations each render takes, to spot regressions when upgrading the captions library.",
+	Args:   cobra.ExactArgs(1),
+	Hidden: true,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		err := cfg.ReadProjectConfig()
+		if err != nil {
+			return err
+		}
+		return cfg.BindPFlags(cmd.LocalFlags())
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n := cfg.GetInt("iterations")
+		if n < 1 {
+			return fmt.Errorf("--iterations must be at least 1, got %d", n)
+		}
+		cmd.SilenceUsage = true
+
+		r, err := transcribe.LoadTranscript(transcribe.FilePath(args[0]))
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "RENDERER\tITERATIONS\tNS/OP\tB/OP\tALLOCS/OP\t")
+		for _, b := range benchmarks {
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+			started := time.Now()
+			for range n {
+				_, err := b.render(converters.NewDeepgramConverter(r))
+				if err != nil {
+					return fmt.Errorf("rendering %s: %w", b.name, err)
+				}
+			}
+			elapsed := time.Since(started)
+			runtime.ReadMemStats(&after)
+
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t\n", b.name, n,
+				elapsed.Nanoseconds()/int64(n),
+				(after.TotalAlloc-before.TotalAlloc)/uint64(n),
+				(after.Mallocs-before.Mallocs)/uint64(n))
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	benchCmd.Flags().IntP("iterations", "n", 100, "how many times each renderer renders the transcript")
+}
+
+func GetCmd(config *config.Config) *cobra.Command {
+	cfg = config
+
+	return benchCmd
+}
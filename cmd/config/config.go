@@ -23,7 +23,7 @@ var configCmd = &cobra.Command{
 		if len(args) == 1 {
 			value := cfg.Get(args[0])
 			if value != nil {
-				fmt.Printf("%s: %v\n", args[0], value)
+				fmt.Printf("%s: %s\n", args[0], printable(args[0], value))
 				return nil
 			}
 		}
@@ -52,7 +52,7 @@ func getAllConfig() {
 	for _, key := range keys {
 		value := cfg.Get(key)
 		if value != nil {
-			fmt.Printf("%s: %v\n", key, value)
+			fmt.Printf("%s: %s\n", key, printable(key, value))
 		}
 	}
 }
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// starterConfig is the config file written by config init.
+const starterConfig = `# dgram configuration
+#
+# These are the defaults for every run. A dgram.yaml file in a project,
+# DGRAM_<KEY> environment variables and flags take precedence over them.
+
+# Deepgram API key, create one at https://console.deepgram.com
+# --api-key and $DEEPGRAM_API_KEY take precedence over it.
+apikey: ""
+
+# Deepgram model used to transcribe, like nova-2, nova-3, whisper-large or base.
+# model: nova-2
+
+# Language of the audio, like en-US, pt-BR or es, or auto to detect it.
+# language: en-US
+
+# Number of files transcribed at the same time.
+# concurrency: 4
+
+# Directory where the outputs of every file are written instead of next to
+# it, mirroring the structure of the inputs: the captions and the audio,
+# transcriptions and graphs directories. The summaries of each run go at its
+# root instead of the current directory.
+# output-dir: ""
+
+# Names of the directories, next to each output, where the extracted audio,
+# the transcripts and the graphs go.
+# audio-dir-name: .audio
+# transcriptions-dir-name: .transcriptions
+# graphs-dir-name: .graphs
+`
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a starter config file",
+	Long:  "Write a commented starter config file to the default config path, asking before replacing an existing one.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := cfg.Path()
+		if err != nil {
+			return err
+		}
+
+		force, _ := cmd.Flags().GetBool("force")
+		if _, err := os.Stat(path); err == nil && !force {
+			fmt.Printf("Config file %q already exists, overwrite it? [y/N] ", path)
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+				fmt.Println("Leaving the config file as it is.")
+				return nil
+			}
+		}
+
+		err = os.MkdirAll(filepath.Dir(path), 0750)
+		if err != nil {
+			return fmt.Errorf("creating config dir: %w", err)
+		}
+
+		// the config holds the API key, keep it private
+		err = os.WriteFile(path, []byte(starterConfig), 0600)
+		if err != nil {
+			return fmt.Errorf("writing config: %w", err)
+		}
+
+		fmt.Printf("Wrote a starter config to %q, set your API key with: dgram config set apikey <key>\n", path)
+		return nil
+	},
+}
+
+func init() {
+	initCmd.Flags().Bool("force", false, "overwrite an existing config file without asking")
+	configCmd.AddCommand(initCmd)
+}
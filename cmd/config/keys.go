@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// secretKeys are the config keys whose values are masked when printed.
+var secretKeys = []string{"apikey"}
+
+// visibleSecretChars is how many of the last characters of a secret are
+// printed.
+const visibleSecretChars = 4
+
+// printable returns value as it should be printed for key, with secrets
+// masked except for their last characters.
+func printable(key string, value any) string {
+	s := fmt.Sprint(value)
+	for _, secret := range secretKeys {
+		if strings.EqualFold(key, secret) {
+			if len(s) <= visibleSecretChars {
+				return strings.Repeat("*", len(s))
+			}
+			return strings.Repeat("*", len(s)-visibleSecretChars) + s[len(s)-visibleSecretChars:]
+		}
+	}
+	return s
+}
+
+var setCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a configuration value",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg.Set(args[0], args[1])
+		err := cfg.Write()
+		if err != nil {
+			return fmt.Errorf("writing config: %w", err)
+		}
+		fmt.Printf("%s: %s\n", args[0], printable(args[0], args[1]))
+		return nil
+	},
+}
+
+var getCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a configuration value",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		value := cfg.Get(args[0])
+		if value == nil {
+			return fmt.Errorf("%s is not set", args[0])
+		}
+		fmt.Printf("%s: %s\n", args[0], printable(args[0], value))
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(setCmd)
+	configCmd.AddCommand(getCmd)
+}
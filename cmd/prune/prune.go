@@ -0,0 +1,48 @@
+package prune
+
+import (
+	"dgram/cmd/transcribe"
+	"dgram/lib/config"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	cfg *config.Config
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune-audio",
+	Short: "remove the audio extracted from sources that were deleted, in the audio directories of the given files",
+	Args:  cobra.MinimumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		err := cfg.ReadProjectConfig()
+		if err != nil {
+			return err
+		}
+		return cfg.BindPFlags(cmd.LocalFlags())
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		files, err := transcribe.InputFiles(args)
+		if err != nil {
+			return fmt.Errorf("getting file paths: %w", err)
+		}
+		cmd.SilenceUsage = true
+
+		return transcribe.PruneAudio(files, cfg.GetBool("dry-run"))
+	},
+}
+
+func init() {
+	flags := pruneCmd.Flags()
+	flags.String("output-dir", "", "directory the audio was extracted to with transcribe --output-dir")
+	flags.String("audio-dir-name", ".audio", "name of the directory, next to each output, where extracted audio goes")
+	flags.Bool("dry-run", false, "list the audio files that would be removed without removing them")
+}
+
+func GetCmd(config *config.Config) *cobra.Command {
+	cfg = config
+
+	return pruneCmd
+}
@@ -0,0 +1,53 @@
+package render
+
+import (
+	"dgram/cmd/transcribe"
+	"dgram/lib/config"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	cfg *config.Config
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "render captions from existing transcripts, without calling Deepgram or ffmpeg",
+	Args:  cobra.MinimumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		err := cfg.ReadProjectConfig()
+		if err != nil {
+			return err
+		}
+		return cfg.BindPFlags(cmd.LocalFlags())
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := transcribe.ValidateRenderFlags()
+		if err != nil {
+			return err
+		}
+
+		files, err := transcribe.InputFiles(args)
+		if err != nil {
+			return fmt.Errorf("getting file paths: %w", err)
+		}
+
+		cmd.SilenceUsage = true
+
+		return transcribe.Render(cmd.Context(), files)
+	},
+}
+
+func init() {
+	flags := renderCmd.Flags()
+	transcribe.AddRenderFlags(flags)
+	flags.BoolP("quiet", "q", false, "only write warnings and errors, to stderr, leaving out the messages about what's being done to each file")
+}
+
+func GetCmd(config *config.Config) *cobra.Command {
+	cfg = config
+
+	return renderCmd
+}
@@ -1,11 +1,19 @@
 package cmd
 
 import (
+	"dgram/cmd/bench"
 	configCmd "dgram/cmd/config"
+	"dgram/cmd/prune"
+	"dgram/cmd/render"
+	"dgram/cmd/stream"
+	"dgram/cmd/summary"
 	"dgram/cmd/transcribe"
 	"dgram/lib/config"
+	"dgram/lib/version"
+	"errors"
 	"fmt"
 	"github.com/spf13/cobra"
+	"io/fs"
 	"os"
 )
 
@@ -17,20 +25,43 @@ func init() {
 	cfg = config.NewConfig(appName)
 	rootCmd.AddCommand(configCmd.GetCmd(cfg))
 	rootCmd.AddCommand(transcribe.GetCmd(cfg))
+	rootCmd.AddCommand(render.GetCmd(cfg))
+	rootCmd.AddCommand(summary.GetCmd(cfg))
+	rootCmd.AddCommand(stream.GetCmd(cfg))
+	rootCmd.AddCommand(prune.GetCmd(cfg))
+	rootCmd.AddCommand(bench.GetCmd(cfg))
 
+	rootCmd.PersistentFlags().StringVar(&cfg.File, "config", "", "config file to use instead of the user config")
 }
 
 var rootCmd = &cobra.Command{
-	Use:   appName,
-	Short: "Get the transcription of video and audio files using Deepgram.",
+	Use:     appName,
+	Short:   "Get the transcription of video and audio files using Deepgram.",
+	Version: version.Dgram(),
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		return cfg.Read()
+		err := cfg.Read()
+		if errors.Is(err, fs.ErrNotExist) && isConfigCmd(cmd) {
+			// the config commands are how a new --config file gets written
+			return nil
+		}
+		return err
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return nil
 	},
 }
 
+// isConfigCmd reports whether cmd is the config command or one of its
+// subcommands.
+func isConfigCmd(cmd *cobra.Command) bool {
+	for ; cmd != nil; cmd = cmd.Parent() {
+		if cmd.Name() == "config" {
+			return true
+		}
+	}
+	return false
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
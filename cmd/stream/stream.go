@@ -0,0 +1,250 @@
+package stream
+
+import (
+	"dgram/lib/config"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/andrerfcsantos/deepgram-go-captions/converters"
+	"github.com/andrerfcsantos/deepgram-go-captions/renderers"
+	restv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+	wsv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/websocket/interfaces"
+	interfaces "github.com/deepgram/deepgram-go-sdk/pkg/client/interfaces"
+	client "github.com/deepgram/deepgram-go-sdk/pkg/client/listen"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cfg *config.Config
+)
+
+// finalizeTimeout is how long to wait for the last results once the stream
+// ends, before closing the connection anyway.
+const finalizeTimeout = 5 * time.Second
+
+var streamCmd = &cobra.Command{
+	Use:   "stream <url|->",
+	Short: "transcribe a live stream, like an internet radio URL or audio piped to stdin, as it plays",
+	Args:  cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		err := cfg.ReadProjectConfig()
+		if err != nil {
+			return err
+		}
+		return cfg.BindPFlags(cmd.LocalFlags())
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		if cfg.APIKey() == "" {
+			return fmt.Errorf("no Deepgram API key configured, set one with: dgram config set apikey <key>, $DEEPGRAM_API_KEY or --api-key")
+		}
+
+		// raw audio has no header to tell Deepgram its sample rate
+		if cfg.GetString("encoding") != "" && cfg.GetInt("sample-rate") <= 0 {
+			return fmt.Errorf("--encoding needs the --sample-rate of the audio")
+		}
+
+		audio, err := openAudio(args[0])
+		if err != nil {
+			return err
+		}
+		defer audio.Close()
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		client.Init(client.InitLib{
+			LogLevel: client.LogLevelStandard,
+		})
+
+		callback := &transcriptCallback{interim: cfg.GetBool("interim"), finalized: make(chan struct{})}
+		options := &interfaces.LiveTranscriptionOptions{
+			Model:          cfg.GetString("model"),
+			Language:       cfg.GetString("language"),
+			Punctuate:      true,
+			SmartFormat:    true,
+			Diarize:        cfg.GetBool("diarize"),
+			InterimResults: cfg.GetBool("interim"),
+			Encoding:       cfg.GetString("encoding"),
+			SampleRate:     cfg.GetInt("sample-rate"),
+			Channels:       cfg.GetInt("channels"),
+		}
+		dg, err := client.NewWSUsingCallback(ctx, cfg.APIKey(), &interfaces.ClientOptions{}, options, callback)
+		if err != nil {
+			return fmt.Errorf("creating deepgram streaming client: %w", err)
+		}
+		if !dg.Connect() {
+			return fmt.Errorf("connecting to deepgram")
+		}
+
+		streamed := make(chan error, 1)
+		go func() {
+			streamed <- dg.Stream(audio)
+		}()
+
+		select {
+		case err = <-streamed:
+		case <-ctx.Done():
+			// stop reading, the stream may never end on its own
+			audio.Close()
+		}
+
+		// ask for the results of the audio sent so far before closing
+		if dg.Finalize() == nil {
+			select {
+			case <-callback.finalized:
+			case <-time.After(finalizeTimeout):
+			}
+		}
+		dg.Stop()
+
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF && ctx.Err() == nil {
+			return fmt.Errorf("streaming %q: %w", args[0], err)
+		}
+
+		if path := cfg.GetString("srt"); path != "" {
+			return writeSRT(callback.transcript(), path)
+		}
+		return nil
+	},
+}
+
+// openAudio opens the audio streamed from source, the URL of a stream, - for
+// stdin, or a local file.
+func openAudio(source string) (io.ReadCloser, error) {
+	switch {
+	case source == "-":
+		return os.Stdin, nil
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		res, err := http.Get(source)
+		if err != nil {
+			return nil, fmt.Errorf("opening stream %q: %w", source, err)
+		}
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			return nil, fmt.Errorf("opening stream %q: %s", source, res.Status)
+		}
+		return res.Body, nil
+	default:
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, fmt.Errorf("opening %q: %w", source, err)
+		}
+		return f, nil
+	}
+}
+
+// transcriptCallback prints the transcripts Deepgram sends as they arrive,
+// and keeps the words of the final ones.
+type transcriptCallback struct {
+	interim   bool
+	finalized chan struct{}
+	once      sync.Once
+
+	mu       sync.Mutex
+	words    []restv1.Word
+	duration float64
+}
+
+func (c *transcriptCallback) Message(mr *wsv1.MessageResponse) error {
+	if mr.FromFinalize {
+		defer c.once.Do(func() { close(c.finalized) })
+	}
+	if len(mr.Channel.Alternatives) == 0 {
+		return nil
+	}
+
+	alt := mr.Channel.Alternatives[0]
+	text := strings.TrimSpace(alt.Transcript)
+	if text == "" {
+		return nil
+	}
+	if !mr.IsFinal {
+		if c.interim {
+			fmt.Printf("[interim] %s\n", text)
+		}
+		return nil
+	}
+	fmt.Println(text)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, w := range alt.Words {
+		c.words = append(c.words, restv1.Word{
+			Word:           w.Word,
+			Start:          w.Start,
+			End:            w.End,
+			Confidence:     w.Confidence,
+			PunctuatedWord: w.PunctuatedWord,
+			Speaker:        w.Speaker,
+		})
+	}
+	c.duration = max(c.duration, mr.Start+mr.Duration)
+	return nil
+}
+
+func (c *transcriptCallback) Error(er *wsv1.ErrorResponse) error {
+	fmt.Fprintf(os.Stderr, "Deepgram error: %s (%s)\n", er.ErrMsg, er.ErrCode)
+	return nil
+}
+
+func (c *transcriptCallback) Open(*wsv1.OpenResponse) error                   { return nil }
+func (c *transcriptCallback) Metadata(*wsv1.MetadataResponse) error           { return nil }
+func (c *transcriptCallback) SpeechStarted(*wsv1.SpeechStartedResponse) error { return nil }
+func (c *transcriptCallback) UtteranceEnd(*wsv1.UtteranceEndResponse) error   { return nil }
+func (c *transcriptCallback) Close(*wsv1.CloseResponse) error                 { return nil }
+func (c *transcriptCallback) UnhandledEvent([]byte) error                     { return nil }
+
+// transcript returns the final results received so far as a prerecorded
+// response, so they can be rendered like the transcripts of files.
+func (c *transcriptCallback) transcript() *restv1.PreRecordedResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &restv1.PreRecordedResponse{
+		Metadata: &restv1.Metadata{Duration: c.duration},
+		Results: &restv1.Result{
+			Channels: []restv1.Channel{{Alternatives: []restv1.Alternative{{Words: c.words}}}},
+		},
+	}
+}
+
+// writeSRT writes the captions of r to path.
+func writeSRT(r *restv1.PreRecordedResponse, path string) error {
+	out, err := renderers.SRT(converters.NewDeepgramConverter(r))
+	if err != nil {
+		return fmt.Errorf("rendering SRT: %w", err)
+	}
+	err = os.WriteFile(path, []byte(out), 0644)
+	if err != nil {
+		return fmt.Errorf("writing SRT file %q: %w", path, err)
+	}
+	fmt.Fprintf(os.Stderr, "Captions written to %q\n", path)
+	return nil
+}
+
+func init() {
+	flags := streamCmd.Flags()
+	flags.String("api-key", "", "Deepgram API key, over $DEEPGRAM_API_KEY and the apikey config key")
+	flags.String("language", "en-US", "language of the audio, like pt-BR or es")
+	flags.String("model", "nova-2", "Deepgram model to transcribe with, like nova-3")
+	flags.Bool("diarize", true, "tell the speakers apart, use --diarize=false for single-speaker streams")
+	flags.Bool("interim", true, "also print the interim transcripts Deepgram sends before the final ones, prefixed with [interim]")
+	flags.String("encoding", "", "encoding of raw audio without a container, like linear16 or mulaw, needs --sample-rate")
+	flags.Int("sample-rate", 0, "sample rate of the raw audio, in Hz, like 16000")
+	flags.Int("channels", 0, "number of channels of the raw audio, Deepgram assumes 1 when not given")
+	flags.String("srt", "", "write the captions of the final transcripts to this SRT file when the stream ends")
+}
+
+func GetCmd(config *config.Config) *cobra.Command {
+	cfg = config
+
+	return streamCmd
+}
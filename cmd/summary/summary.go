@@ -0,0 +1,47 @@
+package summary
+
+import (
+	"dgram/cmd/transcribe"
+	"dgram/lib/config"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	cfg *config.Config
+)
+
+var summaryCmd = &cobra.Command{
+	Use:   "summary",
+	Short: "write the words per minute summary of existing transcripts, without calling Deepgram",
+	Args:  cobra.MinimumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		err := cfg.ReadProjectConfig()
+		if err != nil {
+			return err
+		}
+		return cfg.BindPFlags(cmd.LocalFlags())
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		files, err := transcribe.InputFiles(args)
+		if err != nil {
+			return fmt.Errorf("getting file paths: %w", err)
+		}
+		cmd.SilenceUsage = true
+
+		return transcribe.Summarize(files)
+	},
+}
+
+func init() {
+	flags := summaryCmd.Flags()
+	flags.String("output-dir", "", "directory the transcripts were written to with transcribe --output-dir, where the summary is written too")
+	transcribe.AddSummaryFlags(flags)
+}
+
+func GetCmd(config *config.Config) *cobra.Command {
+	cfg = config
+
+	return summaryCmd
+}
@@ -0,0 +1,485 @@
+package transcribe
+
+import (
+	"context"
+	"dgram/lib/fsys"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	api "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest"
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+)
+
+// FileResult is the outcome of transcribing a file, as written to the summary
+// of a run, wpms.json, and to each line of results.jsonl.
+type FileResult struct {
+	// File is the path of the input, as given to transcribe.
+	File string `json:"file"`
+	// WPM is how many words per minute are spoken in the file, 0 when
+	// Deepgram reported no duration.
+	WPM float64 `json:"wpm"`
+	// Duration is the duration of the audio, in seconds.
+	Duration float64 `json:"duration"`
+	// WordCount is how many words were transcribed, across every channel.
+	WordCount int `json:"word_count"`
+	// Language is the language Deepgram detected, the one given with
+	// --language when nothing was detected, or "unknown".
+	Language string `json:"language"`
+	// Model is the Deepgram model the file was transcribed with.
+	Model string `json:"model,omitempty"`
+	// Warnings are the issues found in the file that may need a closer look.
+	Warnings []string `json:"warnings,omitempty"`
+
+	TurnTaking *turnTaking `json:"-"`
+}
+
+type jobResult struct {
+	FileResult FileResult
+	Error      error
+	// Skipped is set for files that were left out of the batch without it
+	// being an error, like files still being downloaded.
+	Skipped bool
+}
+
+const resultsFile = "results.jsonl"
+
+// summaryPath returns where the summary file name of a run is written, inside
+// --output-dir unless name is an absolute path.
+func summaryPath(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(cfg.GetString("output-dir"), name)
+}
+
+// createSummaryDirs creates the directories the summary files are written to.
+func createSummaryDirs() error {
+	for _, name := range []string{resultsFile, cfg.GetString("summary-file")} {
+		dir := filepath.Dir(summaryPath(name))
+		err := os.MkdirAll(dir, os.ModePerm)
+		if err != nil {
+			return fmt.Errorf("creating output directory %q: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// resultLog appends the result of each file to resultsFile as soon as it's
+// known, one JSON object per line, so the outcome of a batch is on disk even
+// while it's still running.
+type resultLog struct {
+	f   *os.File
+	enc *json.Encoder
+}
+
+type resultLine struct {
+	FileResult
+	Error string `json:"error,omitempty"`
+}
+
+func createResultLog() (*resultLog, error) {
+	path := summaryPath(resultsFile)
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating %s: %w", path, err)
+	}
+	return &resultLog{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (l *resultLog) write(result jobResult) error {
+	line := resultLine{FileResult: result.FileResult}
+	if result.Error != nil {
+		line.Error = result.Error.Error()
+	}
+
+	err := l.enc.Encode(line)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", l.f.Name(), err)
+	}
+	return nil
+}
+
+func (l *resultLog) Close() error {
+	return l.f.Close()
+}
+
+// newFileResult returns the words per minute, duration, word count and
+// language of the transcript r of file. Transcripts without a duration, of
+// silent or corrupt files, get 0 words per minute and a warning.
+func newFileResult(file string, r *interfacesv1.PreRecordedResponse) FileResult {
+	nWords := 0
+	for _, c := range r.Results.Channels {
+		nWords += len(c.Alternatives[0].Words)
+	}
+
+	result := FileResult{File: file, Language: fileLanguage(r), Model: transcriptModel(r), Duration: r.Metadata.Duration, WordCount: nWords}
+	if r.Metadata.Duration <= 0 {
+		result.Warnings = append(result.Warnings, "Deepgram reported no duration, the file may be silent or corrupt")
+		return result
+	}
+	result.WPM = float64(nWords) / (r.Metadata.Duration / 60)
+	return result
+}
+
+// sortByWPM sorts wpms from the fastest file to the slowest.
+func sortByWPM(wpms []FileResult) {
+	slices.SortFunc(wpms, func(a, b FileResult) int {
+		if a.WPM < b.WPM {
+			return 1
+		}
+		if a.WPM > b.WPM {
+			return -1
+		}
+
+		return 0
+	})
+}
+
+// logThroughput prints how many files the batch processed, how many minutes
+// of audio they have and how many of those minutes were processed per minute,
+// which helps tuning --concurrency.
+func logThroughput(totals *batchTotals, failed int, elapsed time.Duration) {
+	if len(totals.files)+failed == 0 {
+		return
+	}
+
+	minutes := totals.seconds / 60
+	infof("Processed %d files (%d failed) with %.1f minutes of audio in %s, %.1f minutes of audio per minute\n",
+		len(totals.files)+failed, failed, minutes, elapsed.Round(10*time.Millisecond), minutes/elapsed.Minutes())
+}
+
+// logWarnings lists the files of warned with their warnings, like audio that
+// may hurt transcription quality.
+func logWarnings(warned []FileResult) {
+	if len(warned) > 0 {
+		logf("Some files may need a closer look:\n")
+		for _, w := range warned {
+			logf("  - %v (%v)\n", w.File, strings.Join(w.Warnings, "; "))
+		}
+	}
+}
+
+// batchTotals keeps what the end of a batch needs from the results of its
+// files, so the results themselves don't stay in memory until it ends.
+type batchTotals struct {
+	// files are the files processed without errors, in the order they
+	// finished.
+	files   []string
+	seconds float64
+	warned  []FileResult
+	// indexed are the files and their words per minute, with --index.
+	indexed []FileResult
+	// languages are the files of each language, with --language-detect-report.
+	languages map[string][]string
+	// turnTaking are the metrics of each file, with --turn-taking.
+	turnTaking map[string]*turnTaking
+}
+
+func newBatchTotals() *batchTotals {
+	return &batchTotals{languages: make(map[string][]string), turnTaking: make(map[string]*turnTaking)}
+}
+
+func (t *batchTotals) add(r FileResult) {
+	t.files = append(t.files, r.File)
+	t.seconds += r.Duration
+	if len(r.Warnings) > 0 {
+		t.warned = append(t.warned, FileResult{File: r.File, Warnings: r.Warnings})
+	}
+	if cfg.GetBool("index") {
+		t.indexed = append(t.indexed, FileResult{File: r.File, WPM: r.WPM})
+	}
+	if cfg.GetBool("language-detect-report") {
+		t.languages[r.Language] = append(t.languages[r.Language], r.File)
+	}
+	if r.TurnTaking != nil {
+		t.turnTaking[r.File] = r.TurnTaking
+	}
+}
+
+// summaryWriter writes the summary of a run, --summary-file and wpms.csv with
+// --csv, adding each file as soon as it's done. The files are in the order
+// they finished.
+type summaryWriter struct {
+	f       *os.File
+	n       int
+	csvFile *os.File
+	csv     *csv.Writer
+}
+
+func createSummary() (*summaryWriter, error) {
+	path := summaryPath(cfg.GetString("summary-file"))
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating %s: %w", path, err)
+	}
+	s := &summaryWriter{f: f}
+
+	if cfg.GetBool("csv") {
+		path := summaryPath("wpms.csv")
+		s.csvFile, err = os.Create(path)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("creating %s: %w", path, err)
+		}
+		s.csv = csv.NewWriter(s.csvFile)
+		s.csv.Write([]string{"file", "wpm", "duration", "word_count"})
+	}
+	return s, nil
+}
+
+// write adds r to the summary.
+func (s *summaryWriter) write(r FileResult) error {
+	data, err := json.MarshalIndent(r, "  ", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling wpms: %w", err)
+	}
+	sep := ",\n  "
+	if s.n == 0 {
+		sep = "[\n  "
+	}
+	_, err = fmt.Fprintf(s.f, "%s%s", sep, data)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", s.f.Name(), err)
+	}
+	s.n++
+
+	if s.csv != nil {
+		s.csv.Write([]string{
+			r.File,
+			strconv.FormatFloat(r.WPM, 'f', 2, 64),
+			strconv.FormatFloat(r.Duration, 'f', 3, 64),
+			strconv.Itoa(r.WordCount),
+		})
+		s.csv.Flush()
+		if err := s.csv.Error(); err != nil {
+			return fmt.Errorf("writing %s: %w", s.csvFile.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Close ends the summary, which isn't valid JSON until then.
+func (s *summaryWriter) Close() error {
+	end := "\n]"
+	if s.n == 0 {
+		end = "[]"
+	}
+	_, err := s.f.WriteString(end)
+	if err != nil {
+		s.f.Close()
+		return fmt.Errorf("writing %s: %w", s.f.Name(), err)
+	}
+	err = s.f.Close()
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", s.f.Name(), err)
+	}
+
+	if s.csvFile != nil {
+		err = s.csvFile.Close()
+		if err != nil {
+			return fmt.Errorf("writing %s: %w", s.csvFile.Name(), err)
+		}
+	}
+	return nil
+}
+
+// runJobs processes files with the given number of workers. The returned
+// channel receives one result per file and is closed once every file was
+// processed. Once stopping is closed no more files are started, but the ones
+// being processed are finished unless ctx is cancelled too.
+func runJobs(ctx context.Context, stopping <-chan struct{}, dg *api.Client, files []string, workers int) <-chan jobResult {
+	// keep the buffers small, so huge batches don't allocate room for every
+	// file up front
+	jobs := make(chan string, workers)
+	results := make(chan jobResult, workers)
+
+	var wg sync.WaitGroup
+	var started atomic.Int64
+
+	// with --ramp-up, workers start one after the other over that window
+	// instead of all sending their first request at once
+	rampUp := cfg.GetDuration("ramp-up")
+
+	// Start worker goroutines
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rampUp > 0 && i > 0 {
+				select {
+				case <-time.After(rampUp * time.Duration(i) / time.Duration(workers)):
+				case <-ctx.Done():
+				case <-stopping:
+				}
+			}
+			for file := range jobs {
+				if ctx.Err() != nil || closed(stopping) {
+					// the batch was stopped, drain the remaining jobs
+					continue
+				}
+				n := int(started.Add(1))
+				results <- processJob(withProgress(ctx, n, len(files)), dg, file)
+			}
+		}()
+	}
+
+	// Send jobs to workers
+	go func() {
+		defer close(jobs)
+		for _, file := range files {
+			jobs <- file
+		}
+	}()
+
+	// Wait for all workers to finish
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// closed reports whether ch is closed.
+func closed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// processJob transcribes file and writes all of its outputs.
+func processJob(ctx context.Context, dg *api.Client, file string) jobResult {
+	fp := FilePath(file)
+	failed := func(err error) jobResult {
+		logEvent(ctx, "file_failed", fp, "error", err.Error())
+		return jobResult{FileResult: FileResult{File: file}, Error: err}
+	}
+
+	// Skip files that are currently being downloaded
+	if fsys.IsBeingDownloaded(string(fp)) {
+		progressf(ctx, "Skipping %q - file is currently being downloaded\n", file)
+		logEvent(ctx, "file_skipped", fp, "reason", "downloading")
+		return jobResult{Skipped: true}
+	}
+	logEvent(ctx, "file_started", fp)
+
+	err := os.MkdirAll(fp.OutputDir(), os.ModePerm)
+	if err != nil {
+		return failed(fmt.Errorf("creating output directory %q: %w", fp.OutputDir(), err))
+	}
+
+	// captions piped to stdout shouldn't leave files behind
+	if cfg.GetBool("trace") && !toStdout() {
+		var t *trace
+		ctx, t = withTrace(ctx)
+		started := time.Now()
+		defer func() {
+			err := writeTrace(t, fp, time.Since(started))
+			if err != nil {
+				warnf(ctx, "Can't write the trace of %q: %v\n", file, err)
+			}
+		}()
+	}
+
+	var warnings []string
+	if cfg.GetBool("check-levels") {
+		var err error
+		end := startStage(ctx, "check-levels")
+		warnings, err = checkLevels(fp)
+		end()
+		if err != nil {
+			warnf(ctx, "Can't check audio levels: %v\n", err)
+		}
+		for _, w := range warnings {
+			warnf(ctx, "Warning: %q: %s, transcription quality may suffer\n", file, w)
+		}
+	}
+
+	cacheHit := isCached(fp)
+	if cfg.GetBool("overwrite-srt-only") && !cacheHit {
+		progressf(ctx, "No cached transcript of %q to render captions from, skipping\n", file)
+		logEvent(ctx, "file_skipped", fp, "reason", "not_cached")
+		return jobResult{Skipped: true}
+	}
+	started := time.Now()
+	r, err := ProcessFile(ctx, dg, fp)
+	if err != nil {
+		return failed(fmt.Errorf("processing file %q: %w", file, err))
+	}
+	if r == nil {
+		// not a file we can transcribe
+		logEvent(ctx, "file_skipped", fp, "reason", "unsupported")
+		return jobResult{Skipped: true}
+	}
+	// --print-json prints the response as Deepgram sent it
+	raw := r
+	r = SelectAlternative(ctx, r, fp)
+
+	if cfg.GetBool("transcript-only") {
+		logEvent(ctx, "file_done", fp)
+		result := FileResult{File: file, Language: fileLanguage(r), Model: transcriptModel(r)}
+		if r.Metadata != nil {
+			result.Duration = r.Metadata.Duration
+		}
+		return jobResult{FileResult: result}
+	}
+
+	if cfg.GetBool("meta") {
+		err = writeMeta(r, fp, started, time.Now(), cacheHit)
+		if err != nil {
+			return failed(err)
+		}
+	}
+
+	if cfg.GetBool("print-json") {
+		err = printJSON(raw)
+		if err != nil {
+			return failed(err)
+		}
+	} else if cfg.GetBool("stdout") {
+		err = writeStdout(r)
+		if err != nil {
+			return failed(err)
+		}
+	} else if !cfg.GetBool("summary-only") {
+		err = writeArtifacts(ctx, r, fp)
+		if err != nil {
+			return failed(err)
+		}
+		PreviewSRT(fp)
+	}
+
+	result := newFileResult(file, r)
+	result.Warnings = append(warnings, result.Warnings...)
+	if cfg.GetBool("turn-taking") {
+		result.TurnTaking = computeTurnTaking(r)
+	}
+	logEvent(ctx, "file_done", fp, "wpm", result.WPM)
+	return jobResult{FileResult: result}
+}
+
+// writeArtifacts writes the per-file outputs (graph and captions) for the
+// transcript r of file.
+func writeArtifacts(ctx context.Context, r *interfacesv1.PreRecordedResponse, file FilePath) error {
+	end := startStage(ctx, "graph")
+	err := CreateGraph(r, file)
+	end()
+	if err != nil {
+		return fmt.Errorf("creating graph: %w", err)
+	}
+
+	defer startStage(ctx, "render")()
+	return WriteCaptions(ctx, r, file, cfg.GetBool("force") || cfg.GetBool("overwrite-srt-only"))
+}
@@ -0,0 +1,116 @@
+package transcribe
+
+import (
+	"dgram/lib/fsys"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+)
+
+// sharedReadAttempts is how many times a transcript in the shared cache is read
+// before giving up, since it may live on a network drive other machines write to.
+const sharedReadAttempts = 3
+
+// hashes memoizes contentHash, keyed by file path.
+var hashes sync.Map
+
+// contentHash returns the hash of the contents of file, only hashing each
+// file once per run. Remote files are hashed by their URL.
+func contentHash(file FilePath) (string, error) {
+	if isURL(string(file)) {
+		return urlHash(string(file)), nil
+	}
+	if h, ok := hashes.Load(file); ok {
+		return h.(string), nil
+	}
+
+	h, err := fsys.HashFile(string(file))
+	if err != nil {
+		return "", fmt.Errorf("hashing %q: %w", file, err)
+	}
+	hashes.Store(file, h)
+	return h, nil
+}
+
+// sharedTranscriptPath returns the path of the transcript of file in the
+// shared --cache-dir, or "" if no shared cache is being used.
+func sharedTranscriptPath(file FilePath) (FilePath, error) {
+	dir := cfg.GetString("cache-dir")
+	if dir == "" {
+		return "", nil
+	}
+
+	h, err := contentHash(file)
+	if err != nil {
+		return "", err
+	}
+	return FilePath(filepath.Join(dir, h+"_response.json")), nil
+}
+
+// isCached reports whether there is a transcript for file in any cache that
+// will be used.
+func isCached(file FilePath) bool {
+	if cfg.GetBool("no-cache") || cfg.GetBool("force") {
+		return false
+	}
+	if runManifest.optionsChanged(file) && !cfg.GetBool("overwrite-srt-only") {
+		return false
+	}
+	if transcriptPath(file).Exists() {
+		return true
+	}
+
+	shared, err := sharedTranscriptPath(file)
+	return err == nil && shared != "" && shared.Exists()
+}
+
+// readTranscript reads the Deepgram response stored in path, trying up to
+// attempts times.
+func readTranscript(path FilePath, attempts int) (*interfacesv1.PreRecordedResponse, error) {
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			time.Sleep(time.Duration(i) * 200 * time.Millisecond)
+		}
+
+		var fileData []byte
+		fileData, err = os.ReadFile(string(path))
+		if err != nil {
+			err = fmt.Errorf("reading existing transcript file %q: %w", path, err)
+			continue
+		}
+
+		var r interfacesv1.PreRecordedResponse
+		err = json.Unmarshal(fileData, &r)
+		if err != nil {
+			err = fmt.Errorf("unmarshaling existing transcript file %q: %w", path, err)
+			continue
+		}
+		return &r, nil
+	}
+	return nil, err
+}
+
+// saveTranscript atomically writes the Deepgram response r to path.
+func saveTranscript(path FilePath, r *interfacesv1.PreRecordedResponse) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling file response: %w", err)
+	}
+
+	err = os.MkdirAll(path.Dir(), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("creating transcript directory %q: %w", path.Dir(), err)
+	}
+
+	err = fsys.WriteFileAtomic(string(path), data, 0644)
+	if err != nil {
+		return fmt.Errorf("writing transcript file %q: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package transcribe
+
+import (
+	"dgram/lib/captions"
+	"dgram/lib/config"
+	"testing"
+
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+)
+
+func loadTwoChannels(t *testing.T) *interfacesv1.PreRecordedResponse {
+	t.Helper()
+	r, err := readTranscript("testdata/two_channels.json", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestTwoChannelSpeakers(t *testing.T) {
+	cfg = config.NewConfig("dgram")
+	cfg.Set("diarize", true)
+	r := loadTwoChannels(t)
+
+	merged := captions.ChannelSpeakers(r)
+	if n := len(merged.Results.Channels); n != 1 {
+		t.Fatalf("got %d channels after merging, want 1", n)
+	}
+
+	want := []struct {
+		word  string
+		label string
+	}{
+		{"Hello", "C0-S0"},
+		{"there.", "C0-S0"},
+		{"Fine", "C1-S0"},
+		{"thanks.", "C1-S0"},
+		{"How", "C0-S0"},
+		{"are", "C0-S0"},
+		{"you?", "C0-S0"},
+		{"And", "C1-S0"},
+		{"you?", "C1-S0"},
+	}
+	words := merged.Results.Channels[0].Alternatives[0].Words
+	if len(words) != len(want) {
+		t.Fatalf("got %d merged words, want %d", len(words), len(want))
+	}
+	for i, w := range words {
+		if w.PunctuatedWord != want[i].word {
+			t.Errorf("word %d is %q, want %q", i, w.PunctuatedWord, want[i].word)
+		}
+		if label := channelLabel(*w.Speaker); label != want[i].label {
+			t.Errorf("word %d (%q) is said by %s, want %s", i, w.PunctuatedWord, label, want[i].label)
+		}
+	}
+
+	for _, u := range merged.Results.Utterances {
+		want := "C0-S0"
+		if u.Channel == 1 {
+			want = "C1-S0"
+		}
+		if label := channelLabel(*u.Speaker); label != want {
+			t.Errorf("utterance %q of channel %d is said by %s, want %s", u.Transcript, u.Channel, label, want)
+		}
+	}
+
+	text, err := renderText(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantText := "C0-S0: Hello there.\n\nC1-S0: Fine thanks.\n\nC0-S0: How are you?\n\nC1-S0: And you?\n"
+	if text != wantText {
+		t.Errorf("renderText() = %q, want %q", text, wantText)
+	}
+}
+
+func TestTwoChannelWPM(t *testing.T) {
+	cfg = config.NewConfig("dgram")
+	r := loadTwoChannels(t)
+
+	result := newFileResult("two_channels.mp3", r)
+	if result.WordCount != 9 {
+		t.Errorf("got %d words, want 9", result.WordCount)
+	}
+	if result.WPM != 90 {
+		t.Errorf("got %v WPM, want 90", result.WPM)
+	}
+
+	// each channel alone, over the same duration
+	for c, want := range []float64{50, 40} {
+		channel := *r
+		results := *r.Results
+		results.Channels = r.Results.Channels[c : c+1]
+		channel.Results = &results
+		if wpm := newFileResult("two_channels.mp3", &channel).WPM; wpm != want {
+			t.Errorf("channel %d has %v WPM, want %v", c, wpm, want)
+		}
+	}
+}
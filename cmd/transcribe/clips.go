@@ -0,0 +1,128 @@
+package transcribe
+
+import (
+	"dgram/lib/captions"
+	"dgram/lib/fsys"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/andrerfcsantos/deepgram-go-captions/renderers"
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// clipsPath returns the path of the SRT with only the cues around the
+// keywords of --clip-around for file.
+func clipsPath(file FilePath) string {
+	return filepath.Join(file.OutputDir(), file.OutputName()+".clips.srt")
+}
+
+// rangesPath returns the path of the JSON file listing the time ranges around
+// the keywords of --clip-around for file.
+func rangesPath(file FilePath) string {
+	return filepath.Join(file.OutputDir(), file.OutputName()+".clips.json")
+}
+
+// keywordRanges returns the ranges of r around the keywords of --clip-around.
+func keywordRanges(r *interfacesv1.PreRecordedResponse) []captions.Range {
+	return captions.KeywordRanges(r, cfg.GetStringSlice("clip-around"), cfg.GetDuration("window").Seconds())
+}
+
+// writeClips writes an SRT with only the cues within --window of the
+// keywords of --clip-around, along with the list of their time ranges.
+// Existing files are only replaced when overwrite is set.
+func writeClips(r *interfacesv1.PreRecordedResponse, file FilePath, overwrite bool) error {
+	path := clipsPath(file)
+	if !overwrite && fsys.FileExists(path) {
+		infof("Clips file %q already exists, skipping\n", path)
+		return nil
+	}
+
+	ranges := keywordRanges(r)
+	if len(ranges) == 0 {
+		infof("No mentions of %v in %q\n", cfg.GetStringSlice("clip-around"), file)
+	}
+
+	out, err := renderers.SRT(captions.Within{Converter: newConverter(r), Ranges: ranges})
+	if err != nil {
+		return fmt.Errorf("rendering clips for %s: %w", file, err)
+	}
+	out = captions.TrimWrapped(out)
+	err = os.WriteFile(path, []byte(out), 0644)
+	if err != nil {
+		return fmt.Errorf("writing clips file %q: %w", path, err)
+	}
+
+	if ranges == nil {
+		ranges = []captions.Range{}
+	}
+	data, err := json.MarshalIndent(ranges, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling clip ranges: %w", err)
+	}
+	err = os.WriteFile(rangesPath(file), data, 0644)
+	if err != nil {
+		return fmt.Errorf("writing clip ranges file %q: %w", rangesPath(file), err)
+	}
+	return nil
+}
+
+// clipsDirectory is the directory, next to each output, where the clips of
+// --extract-clips are cut into. Like the other output directories it's never
+// read for inputs, so clips aren't transcribed.
+const clipsDirectory = ".clips"
+
+// clipContainers are the extensions of the containers clips are cut into,
+// keeping the one of the source when ffmpeg can write it.
+var clipContainers = []string{".mp4", ".mov", ".mkv", ".webm", ".avi", ".m4v", ".mp3", ".wav", ".flac", ".ogg", ".opus", ".m4a", ".aac"}
+
+// clipExt returns the extension of the clips cut from file, which picks the
+// container ffmpeg writes them in: the one of file, or of --input-format, or
+// Matroska, which holds any audio or video, when ffmpeg can't write that one
+// or there's none.
+func clipExt(file FilePath) string {
+	if ext := mediaExt(file); slices.Contains(clipContainers, ext) {
+		return ext
+	}
+	return ".mkv"
+}
+
+// clipPath returns the path of the n-th clip cut from file, numbered from 1.
+func clipPath(file FilePath, n int) string {
+	return filepath.Join(file.OutputDir(), clipsDirectory, fmt.Sprintf("%s_clip_%d%s", file.OutputName(), n, clipExt(file)))
+}
+
+// extractClips cuts the given ranges out of file with ffmpeg, one clip per
+// range. Existing clips are only replaced when overwrite is set.
+func extractClips(file FilePath, ranges []captions.Range, overwrite bool) error {
+	if len(ranges) > 0 {
+		dir := filepath.Join(file.OutputDir(), clipsDirectory)
+		err := os.MkdirAll(dir, os.ModePerm)
+		if err != nil {
+			return fmt.Errorf("creating clips directory %q: %w", dir, err)
+		}
+	}
+
+	for i, r := range ranges {
+		path := clipPath(file, i+1)
+		if !overwrite && fsys.FileExists(path) {
+			infof("Clip %q already exists, skipping\n", path)
+			continue
+		}
+
+		infof("Cutting %s-%s of %q into %q\n", captions.Clock(r.Start), captions.Clock(r.End), file, path)
+		err := ffmpeg.
+			Input(string(file), ffmpeg.KwArgs{"ss": r.Start, "to": r.End}).
+			Output(path).
+			OverWriteOutput().
+			Silent(true).
+			Run()
+		if err != nil {
+			return fmt.Errorf("running ffmpeg cutting clip %q: %w", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package transcribe
+
+import (
+	"fmt"
+)
+
+// dedupFiles returns the files with distinct contents, the first one of each,
+// along with the duplicates of each of them, for --dedup.
+func dedupFiles(files []string) ([]string, map[string][]string, error) {
+	unique := make([]string, 0, len(files))
+	duplicates := make(map[string][]string)
+	first := make(map[string]string)
+	for _, file := range files {
+		h, err := contentHash(FilePath(file))
+		if err != nil {
+			return nil, nil, err
+		}
+		if original, ok := first[h]; ok {
+			duplicates[original] = append(duplicates[original], file)
+			continue
+		}
+		first[h] = file
+		unique = append(unique, file)
+	}
+	return unique, duplicates, nil
+}
+
+// shareTranscript caches the transcript of original as the transcript of its
+// duplicate, unless the duplicate has one already.
+func shareTranscript(original, duplicate string) error {
+	if transcriptPath(FilePath(duplicate)).Exists() {
+		return nil
+	}
+
+	r, err := LoadTranscript(FilePath(original))
+	if err != nil {
+		return fmt.Errorf("loading transcript of %q for its duplicate %q: %w", original, duplicate, err)
+	}
+	return saveTranscript(transcriptPath(FilePath(duplicate)), r)
+}
@@ -0,0 +1,62 @@
+package transcribe
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// printPlan prints what transcribing files would do, for --dry-run, without
+// running ffmpeg or calling Deepgram.
+func printPlan(files []string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tTRANSCRIPT\tAUDIO\tCAPTIONS")
+
+	transcribe := 0
+	for _, file := range files {
+		fp := FilePath(file)
+		isVideo := slices.Contains(VideoExtensions, mediaExt(fp))
+		isAudio := slices.Contains(AudioExtensions, mediaExt(fp))
+		remote := isURL(file)
+		if !isVideo && !isAudio && !remote && !cfg.GetBool("force-ffmpeg") {
+			fmt.Fprintf(w, "%s\tskip, not audio or video\t-\t-\n", file)
+			continue
+		}
+
+		transcript, audio := "cached", "-"
+		if !isCached(fp) {
+			transcribe++
+			transcript, audio = "transcribe", "as is"
+			if remote {
+				audio = "fetched by Deepgram"
+			} else if !isAudio {
+				audio = "extract with ffmpeg"
+				if converted := reusableAudio(fp); converted != "" {
+					audio = "extracted, " + string(converted)
+				}
+			}
+		}
+
+		var write []string
+		for _, format := range formats() {
+			if cfg.GetBool("force") || !FilePath(captionPath(fp, format)).Exists() {
+				write = append(write, format)
+			}
+		}
+		captions := "all exist"
+		if len(write) > 0 {
+			captions = "write " + strings.Join(write, ", ")
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", file, transcript, audio, captions)
+	}
+
+	err := w.Flush()
+	if err != nil {
+		return fmt.Errorf("writing plan: %w", err)
+	}
+	fmt.Printf("\n%d files, %d to transcribe with Deepgram\n", len(files), transcribe)
+	return nil
+}
@@ -0,0 +1,126 @@
+package transcribe
+
+import (
+	"dgram/lib/captions"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// probeDuration returns the duration of file in seconds, as ffprobe reports it.
+func probeDuration(file FilePath) (float64, error) {
+	out, err := ffmpeg.Probe(string(file))
+	if err != nil {
+		return 0, fmt.Errorf("running ffprobe on %q: %w", file, err)
+	}
+
+	var probe struct {
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	err = json.Unmarshal([]byte(out), &probe)
+	if err != nil {
+		return 0, fmt.Errorf("reading ffprobe output for %q: %w", file, err)
+	}
+	duration, err := strconv.ParseFloat(probe.Format.Duration, 64)
+	if err != nil {
+		return 0, fmt.Errorf("no duration found by ffprobe for %q", file)
+	}
+	return duration, nil
+}
+
+// fileDuration returns the duration of file in seconds, from its cached
+// transcript if it has one, otherwise from ffprobe.
+func fileDuration(file FilePath) (float64, error) {
+	if isCached(file) {
+		if r, err := LoadTranscript(file); err == nil && r.Metadata != nil {
+			return r.Metadata.Duration, nil
+		}
+	}
+	return probeDuration(file)
+}
+
+// withinDuration returns the files that last between --min-duration and
+// --max-duration, when they're set. Files whose duration can't be found are
+// kept, with a warning.
+func withinDuration(files []string) []string {
+	minDuration, maxDuration := cfg.GetDuration("min-duration"), cfg.GetDuration("max-duration")
+	if minDuration <= 0 && maxDuration <= 0 {
+		return files
+	}
+
+	kept := make([]string, 0, len(files))
+	for _, file := range files {
+		seconds, err := fileDuration(FilePath(file))
+		if err != nil {
+			logf("Can't find the duration of %q, processing it anyway: %v\n", file, err)
+			kept = append(kept, file)
+			continue
+		}
+		duration := time.Duration(seconds * float64(time.Second))
+		if duration < minDuration || maxDuration > 0 && duration > maxDuration {
+			continue
+		}
+		kept = append(kept, file)
+	}
+	return kept
+}
+
+// printEstimate prints how many minutes of audio transcribing files would
+// send to Deepgram, for --estimate, and what they would cost at
+// --rate-per-minute. Only ffprobe is run, files with a cached transcript take
+// their duration from it.
+func printEstimate(files []string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tDURATION\tTRANSCRIPT")
+
+	var total, billed float64
+	failed := 0
+	for _, file := range files {
+		fp := FilePath(file)
+		isMedia := slices.Contains(VideoExtensions, mediaExt(fp)) || slices.Contains(AudioExtensions, mediaExt(fp))
+		if !isMedia && !isURL(file) && !cfg.GetBool("force-ffmpeg") {
+			fmt.Fprintf(w, "%s\t-\tskip, not audio or video\n", file)
+			continue
+		}
+
+		if isCached(fp) {
+			if r, err := LoadTranscript(fp); err == nil && r.Metadata != nil {
+				total += r.Metadata.Duration
+				fmt.Fprintf(w, "%s\t%s\tcached\n", file, captions.Clock(r.Metadata.Duration))
+				continue
+			}
+		}
+
+		duration, err := probeDuration(fp)
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "%s\t?\t%v\n", file, err)
+			continue
+		}
+		total += duration
+		billed += duration
+		fmt.Fprintf(w, "%s\t%s\ttranscribe\n", file, captions.Clock(duration))
+	}
+
+	err := w.Flush()
+	if err != nil {
+		return fmt.Errorf("writing estimate: %w", err)
+	}
+
+	fmt.Printf("\n%d files, %s of audio, %.1f minutes to transcribe with Deepgram\n", len(files), captions.Clock(total), billed/60)
+	if rate := cfg.GetFloat64("rate-per-minute"); rate > 0 {
+		fmt.Printf("Estimated cost: %.2f at %g per minute\n", billed/60*rate, rate)
+	}
+	if failed > 0 {
+		fmt.Printf("The duration of %d files couldn't be found, they're left out of the estimate\n", failed)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package transcribe
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// eventLog writes the lifecycle events of each file for --log-json, one JSON
+// object per line. It's nil when --log-json is off.
+var eventLog *slog.Logger
+
+// newEventLog returns the logger of --log-json, writing events like
+// {"time":"...","event":"file_done","file":"talk.mp4","wpm":151.2} to w.
+func newEventLog(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) > 0 {
+				return a
+			}
+			switch a.Key {
+			case slog.LevelKey:
+				// every event is logged at the same level
+				return slog.Attr{}
+			case slog.MessageKey:
+				return slog.String("event", a.Value.String())
+			}
+			return a
+		},
+	}))
+}
+
+// logEvent logs the event name about file, with the given key-value pairs and
+// the position of the file in the batch being processed in ctx.
+func logEvent(ctx context.Context, name string, file FilePath, args ...any) {
+	if eventLog == nil {
+		return
+	}
+	attrs := append([]any{"file", string(file)}, args...)
+	if p, ok := ctx.Value(progressKey{}).(progress); ok {
+		attrs = append(attrs, "n", p.n, "total", p.total)
+	}
+	eventLog.InfoContext(ctx, name, attrs...)
+}
@@ -0,0 +1,47 @@
+package transcribe
+
+import (
+	"fmt"
+	"os/exec"
+	"slices"
+)
+
+const ffmpegDownload = "https://ffmpeg.org/download.html"
+
+// checkFFmpeg returns an error telling how to install ffmpeg when processing
+// files needs it and it isn't in PATH, so a batch fails upfront instead of
+// file by file. Batches of audio files with nothing to extract don't need it.
+func checkFFmpeg(files []string) error {
+	need := ffmpegNeed(files)
+	if need == "" {
+		return nil
+	}
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("ffmpeg is needed %s, but it isn't installed or isn't in PATH, download it from %s", need, ffmpegDownload)
+	}
+	return nil
+}
+
+// ffmpegNeed returns what ffmpeg is needed for in this run, or "" if it isn't
+// needed.
+func ffmpegNeed(files []string) string {
+	if cfg.GetBool("check-levels") {
+		return "to check the audio levels with --check-levels"
+	}
+	if cfg.GetBool("extract-clips") && len(cfg.GetStringSlice("clip-around")) > 0 {
+		return "to cut the clips of --extract-clips"
+	}
+
+	for _, file := range files {
+		fp := FilePath(file)
+		if isURL(file) || isCached(fp) {
+			continue
+		}
+		video := slices.Contains(VideoExtensions, mediaExt(fp))
+		audio := slices.Contains(AudioExtensions, mediaExt(fp))
+		if (video || !audio && cfg.GetBool("force-ffmpeg")) && reusableAudio(fp) == "" {
+			return fmt.Sprintf("to extract the audio of %q", file)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package transcribe
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+)
+
+// indexFile is the page written by --index, relative to --output-dir.
+const indexFile = "index.html"
+
+var indexTemplate = template.Must(template.New(indexFile).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>dgram graphs</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+td, th { padding: 0.3em 1em; text-align: left; }
+td.wpm { text-align: right; }
+</style>
+</head>
+<body>
+<h1>Graphs</h1>
+<table>
+<tr><th>File</th><th>WPM</th></tr>
+{{- range .}}
+<tr><td><a href="{{.Graph}}">{{.File}}</a></td><td class="wpm">{{printf "%.1f" .WPM}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+type indexEntry struct {
+	File  string
+	Graph string
+	WPM   float64
+}
+
+// writeIndex writes indexFile, linking the graph of each file of wpms along
+// with its words per minute.
+func writeIndex(wpms []FileResult) error {
+	path := summaryPath(indexFile)
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return fmt.Errorf("getting directory of %s: %w", path, err)
+	}
+
+	entries := make([]indexEntry, 0, len(wpms))
+	for _, w := range wpms {
+		graph, err := filepath.Abs(graphPath(FilePath(w.File)))
+		if err != nil {
+			return fmt.Errorf("getting path of the graph of %q: %w", w.File, err)
+		}
+		// relative links keep working when the outputs are moved together
+		if rel, err := filepath.Rel(dir, graph); err == nil {
+			graph = rel
+		}
+		entries = append(entries, indexEntry{File: w.File, Graph: filepath.ToSlash(graph), WPM: w.WPM})
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+	defer f.Close()
+
+	err = indexTemplate.Execute(f, entries)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,70 @@
+package transcribe
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+)
+
+const (
+	// autoLanguage is the --language that asks Deepgram to detect the language.
+	autoLanguage    = "auto"
+	unknownLanguage = "unknown"
+)
+
+// languageReport is the entry of languages.json for a single language.
+type languageReport struct {
+	Count int      `json:"count"`
+	Files []string `json:"files"`
+}
+
+// detectedLanguage returns the language Deepgram detected for r, or
+// unknownLanguage if language detection wasn't used.
+func detectedLanguage(r *interfacesv1.PreRecordedResponse) string {
+	if r.Results != nil {
+		for _, c := range r.Results.Channels {
+			if c.DetectedLanguage != "" {
+				return c.DetectedLanguage
+			}
+		}
+	}
+	return unknownLanguage
+}
+
+// fileLanguage returns the language of the transcript r: the one Deepgram
+// detected or, when nothing was detected, the one it was asked for with
+// --language.
+func fileLanguage(r *interfacesv1.PreRecordedResponse) string {
+	if language := detectedLanguage(r); language != unknownLanguage {
+		return language
+	}
+	if language := transcriptionOptions().Language; language != "" {
+		return language
+	}
+	return unknownLanguage
+}
+
+// writeLanguageReport writes languages.json from the files of each detected
+// language.
+func writeLanguageReport(languages map[string][]string) error {
+	report := make(map[string]languageReport, len(languages))
+	for language, files := range languages {
+		slices.Sort(files)
+		report[language] = languageReport{Count: len(files), Files: files}
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling language report: %w", err)
+	}
+
+	path := summaryPath("languages.json")
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
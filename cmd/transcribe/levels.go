@@ -0,0 +1,63 @@
+package transcribe
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+const (
+	// clippingLevel is the peak level, in dB, from which audio is considered clipped.
+	clippingLevel = -0.1
+	// quietLevel is the mean level, in dB, under which audio is considered too quiet.
+	quietLevel = -40.0
+)
+
+var (
+	maxVolumeRe  = regexp.MustCompile(`max_volume: (-?[\d.]+) dB`)
+	meanVolumeRe = regexp.MustCompile(`mean_volume: (-?[\d.]+) dB`)
+)
+
+// checkLevels runs ffmpeg's volumedetect filter over file and returns warnings
+// for audio that looks clipped or is too quiet to transcribe well.
+func checkLevels(file FilePath) ([]string, error) {
+	var stderr bytes.Buffer
+	err := ffmpeg.
+		Input(string(file)).
+		Output("-", ffmpeg.KwArgs{"af": "volumedetect", "vn": "", "format": "null"}).
+		WithErrorOutput(&stderr).
+		Silent(true).
+		Run()
+	if err != nil {
+		return nil, fmt.Errorf("running ffmpeg volumedetect on %q: %w", file, err)
+	}
+
+	maxVolume, err := parseVolume(maxVolumeRe, stderr.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("reading max volume of %q: %w", file, err)
+	}
+	meanVolume, err := parseVolume(meanVolumeRe, stderr.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("reading mean volume of %q: %w", file, err)
+	}
+
+	var warnings []string
+	if maxVolume >= clippingLevel {
+		warnings = append(warnings, fmt.Sprintf("audio peaks at %.1f dB and may be clipped", maxVolume))
+	}
+	if meanVolume < quietLevel {
+		warnings = append(warnings, fmt.Sprintf("audio is very quiet (mean level %.1f dB)", meanVolume))
+	}
+	return warnings, nil
+}
+
+func parseVolume(re *regexp.Regexp, output []byte) (float64, error) {
+	m := re.FindSubmatch(output)
+	if m == nil {
+		return 0, fmt.Errorf("no volume found in ffmpeg output")
+	}
+	return strconv.ParseFloat(string(m[1]), 64)
+}
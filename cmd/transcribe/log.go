@@ -0,0 +1,79 @@
+package transcribe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// status is where informational messages are written. It is switched to
+// stderr when stdout is reserved for the rendered output.
+var status io.Writer = os.Stdout
+
+// quiet is set by --quiet to leave out the messages of infof and progressf.
+var quiet bool
+
+// statusMu makes each message a single write to status, so the messages of
+// the workers of a batch don't interleave.
+var statusMu sync.Mutex
+
+// logf writes a message to status.
+func logf(format string, a ...any) {
+	msg := fmt.Sprintf(format, a...)
+	statusMu.Lock()
+	defer statusMu.Unlock()
+	io.WriteString(status, msg)
+}
+
+// applyQuiet leaves out the messages of infof and progressf with --quiet. What's
+// left are the warnings and errors, which go to stderr.
+func applyQuiet() {
+	if cfg.GetBool("quiet") {
+		quiet = true
+		status = os.Stderr
+	}
+}
+
+// infof is logf for informational messages about what's being done, which
+// are left out with --quiet.
+func infof(format string, a ...any) {
+	if quiet {
+		return
+	}
+	logf(format, a...)
+}
+
+type progressKey struct{}
+
+// progress is the position of a file in its batch, like 12 of 200.
+type progress struct {
+	n, total int
+}
+
+// withProgress returns a copy of ctx for processing the n-th of total files.
+func withProgress(ctx context.Context, n, total int) context.Context {
+	return context.WithValue(ctx, progressKey{}, progress{n: n, total: total})
+}
+
+// progressf is infof for messages about the file being processed in ctx,
+// which are prefixed with its position in the batch, like [12/200].
+func progressf(ctx context.Context, format string, a ...any) {
+	infof(withPosition(ctx, format), a...)
+}
+
+// warnf is progressf for warnings and errors, which are written even with
+// --quiet.
+func warnf(ctx context.Context, format string, a ...any) {
+	logf(withPosition(ctx, format), a...)
+}
+
+// withPosition prefixes format with the position in the batch of the file
+// being processed in ctx, if any.
+func withPosition(ctx context.Context, format string) string {
+	if p, ok := ctx.Value(progressKey{}).(progress); ok {
+		return fmt.Sprintf("[%d/%d] ", p.n, p.total) + format
+	}
+	return format
+}
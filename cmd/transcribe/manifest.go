@@ -0,0 +1,166 @@
+package transcribe
+
+import (
+	"dgram/lib/fsys"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	interfaces "github.com/deepgram/deepgram-go-sdk/pkg/client/interfaces"
+)
+
+// manifestFile records how the transcript of each file was requested, next
+// to the summaries of the run.
+const manifestFile = ".dgram-manifest.json"
+
+// manifestInterval is how often the manifest is written while files are
+// being transcribed, so a crash loses little of it.
+const manifestInterval = 10 * time.Second
+
+// manifestEntry is how the cached transcript of a file was produced.
+type manifestEntry struct {
+	Hash     string                                      `json:"hash"`
+	Size     int64                                       `json:"size"`
+	ModTime  time.Time                                   `json:"mod_time"`
+	Model    string                                      `json:"model"`
+	Language string                                      `json:"language"`
+	Options  *interfaces.PreRecordedTranscriptionOptions `json:"options"`
+	Response string                                      `json:"response"`
+}
+
+// manifest is the manifest of the transcripts fetched from Deepgram, by
+// input. Cached transcripts whose options differ from the ones of this run are
+// fetched again. Transcripts cached before there was a manifest have no entry
+// and are still used.
+type manifest struct {
+	mu    sync.Mutex
+	path  string
+	files map[string]manifestEntry
+	// dirty is set when some entries haven't been written yet
+	dirty   bool
+	written time.Time
+}
+
+// runManifest is the manifest of the transcribe run, nil outside of one.
+var runManifest *manifest
+
+// loadManifest reads the manifest of earlier runs, if there is one.
+func loadManifest() (*manifest, error) {
+	m := &manifest{path: summaryPath(manifestFile), files: make(map[string]manifestEntry)}
+	data, err := os.ReadFile(m.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest %q: %w", m.path, err)
+	}
+	err = json.Unmarshal(data, &m.files)
+	if err != nil {
+		return nil, fmt.Errorf("parsing manifest %q: %w", m.path, err)
+	}
+	return m, nil
+}
+
+// optionsChanged reports whether the transcript of file was requested with
+// other options than the ones of this run.
+func (m *manifest) optionsChanged(file FilePath) bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	entry, ok := m.files[string(file)]
+	m.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	recorded, err := json.Marshal(entry.Options)
+	if err != nil {
+		return true
+	}
+	current, err := json.Marshal(transcriptionOptions())
+	return err != nil || string(recorded) != string(current)
+}
+
+// record adds the transcript of file, just fetched with the options of this
+// run, to the manifest. The manifest is written at most every
+// manifestInterval, and by flush at the end of the run. The hash of file is
+// reused from its entry when its size and modification time are the same.
+func (m *manifest) record(file FilePath, response FilePath) error {
+	if m == nil {
+		return nil
+	}
+	var size int64
+	var modTime time.Time
+	if info, err := os.Stat(string(file)); err == nil {
+		size, modTime = info.Size(), info.ModTime()
+	}
+	m.mu.Lock()
+	previous, ok := m.files[string(file)]
+	m.mu.Unlock()
+
+	hash := previous.Hash
+	if !ok || hash == "" || previous.Size != size || !previous.ModTime.Equal(modTime) || isURL(string(file)) {
+		var err error
+		hash, err = contentHash(file)
+		if err != nil {
+			return err
+		}
+	}
+	options := transcriptionOptions()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[string(file)] = manifestEntry{
+		Hash:     hash,
+		Size:     size,
+		ModTime:  modTime,
+		Model:    options.Model,
+		Language: options.Language,
+		Options:  options,
+		Response: string(response),
+	}
+	m.dirty = true
+	if time.Since(m.written) < manifestInterval {
+		return nil
+	}
+	return m.write()
+}
+
+// flush writes the manifest if it has entries that weren't written yet.
+func (m *manifest) flush() error {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.dirty {
+		return nil
+	}
+	return m.write()
+}
+
+// write writes the manifest atomically, so an interrupted run can't leave it
+// truncated. m.mu must be held.
+func (m *manifest) write() error {
+	data, err := json.MarshalIndent(m.files, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling manifest: %w", err)
+	}
+	err = os.MkdirAll(filepath.Dir(m.path), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("creating manifest directory: %w", err)
+	}
+	err = fsys.WriteFileAtomic(m.path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("writing manifest %q: %w", m.path, err)
+	}
+	m.dirty = false
+	m.written = time.Now()
+	return nil
+}
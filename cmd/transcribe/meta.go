@@ -0,0 +1,89 @@
+package transcribe
+
+import (
+	"dgram/lib/version"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+	interfaces "github.com/deepgram/deepgram-go-sdk/pkg/client/interfaces"
+)
+
+const sdkModule = "github.com/deepgram/deepgram-go-sdk"
+
+// transcriptMeta describes how the transcript of a file was produced.
+type transcriptMeta struct {
+	File          string                                      `json:"file"`
+	Transcript    string                                      `json:"transcript"`
+	Model         string                                      `json:"model"`
+	Language      string                                      `json:"language"`
+	Options       *interfaces.PreRecordedTranscriptionOptions `json:"options"`
+	SDKVersion    string                                      `json:"sdk_version"`
+	DgramVersion  string                                      `json:"dgram_version"`
+	Started       time.Time                                   `json:"started"`
+	Finished      time.Time                                   `json:"finished"`
+	Elapsed       float64                                     `json:"elapsed"`
+	AudioDuration float64                                     `json:"audio_duration"`
+	RequestID     string                                      `json:"request_id"`
+	CacheHit      bool                                        `json:"cache_hit"`
+}
+
+// writeMeta writes the .meta.json file of file. Options are the ones
+// requested for this run; for cache hits the transcript may have been produced
+// with different ones.
+func writeMeta(r *interfacesv1.PreRecordedResponse, file FilePath, started, finished time.Time, cacheHit bool) error {
+	options := transcriptionOptions()
+	meta := transcriptMeta{
+		File:         string(file),
+		Transcript:   string(transcriptPath(file)),
+		Model:        transcriptModel(r),
+		Language:     options.Language,
+		Options:      options,
+		SDKVersion:   version.Module(sdkModule),
+		DgramVersion: version.Dgram(),
+		Started:      started,
+		Finished:     finished,
+		Elapsed:      finished.Sub(started).Seconds(),
+		RequestID:    r.RequestID,
+		CacheHit:     cacheHit,
+	}
+
+	if r.Metadata != nil {
+		meta.AudioDuration = r.Metadata.Duration
+		if r.Metadata.RequestID != "" {
+			meta.RequestID = r.Metadata.RequestID
+		}
+	}
+
+	if r.Results != nil && len(r.Results.Channels) > 0 && r.Results.Channels[0].DetectedLanguage != "" {
+		meta.Language = r.Results.Channels[0].DetectedLanguage
+	}
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling metadata: %w", err)
+	}
+
+	path := filepath.Join(file.OutputDir(), file.OutputName()+".meta.json")
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("writing metadata file %q: %w", path, err)
+	}
+	return nil
+}
+
+// transcriptModel returns the model Deepgram reports having transcribed r
+// with, or the one requested with --model when it doesn't report any.
+func transcriptModel(r *interfacesv1.PreRecordedResponse) string {
+	if r.Metadata != nil {
+		for _, info := range r.Metadata.ModelInfo {
+			if info.Name != "" {
+				return info.Name
+			}
+		}
+	}
+	return transcriptionOptions().Model
+}
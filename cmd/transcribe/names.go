@@ -0,0 +1,70 @@
+package transcribe
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// namePlaceholders are the placeholders of --name-template.
+var namePlaceholders = []string{"{base}", "{dir}", "{lang}", "{model}", "{date}"}
+
+var placeholderRe = regexp.MustCompile(`\{[^{}]*\}`)
+
+// runDate is the date {date} is replaced with, the same for every file of a
+// run even when it goes past midnight.
+var runDate = sync.OnceValue(func() string {
+	return time.Now().Format(time.DateOnly)
+})
+
+// checkNameTemplate returns an error when --name-template has placeholders
+// other than namePlaceholders, or would name outputs in other directories.
+func checkNameTemplate() error {
+	template := cfg.GetString("name-template")
+	for _, p := range placeholderRe.FindAllString(template, -1) {
+		if !slices.Contains(namePlaceholders, p) {
+			return fmt.Errorf("unknown placeholder %s in --name-template, supported placeholders are: %s", p, strings.Join(namePlaceholders, ", "))
+		}
+	}
+	if strings.ContainsAny(template, `/\`) {
+		return fmt.Errorf("--name-template names files, it can't have path separators, got %q", template)
+	}
+	return nil
+}
+
+// dirBase returns the name of the directory of f, from its absolute path so
+// files in the current directory get its name rather than ".".
+func dirBase(f FilePath) string {
+	dir, err := filepath.Abs(f.Dir())
+	if err != nil {
+		return filepath.Base(f.Dir())
+	}
+	return filepath.Base(dir)
+}
+
+// OutputName returns the name, without extension, of the captions, graph and
+// other outputs of f, other than its cached transcript: its Base, or the name
+// given by --name-template, like {base}_{lang}_{model} for talk_en-US_nova-2.
+func (f FilePath) OutputName() string {
+	template := cfg.GetString("name-template")
+	if template == "" {
+		return f.Base()
+	}
+
+	opts := transcriptionOptions()
+	lang := opts.Language
+	if opts.DetectLanguage {
+		lang = autoLanguage
+	}
+	return strings.NewReplacer(
+		"{base}", f.Base(),
+		"{dir}", dirBase(f),
+		"{lang}", lang,
+		"{model}", opts.Model,
+		"{date}", runDate(),
+	).Replace(template)
+}
@@ -0,0 +1,98 @@
+package transcribe
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	interfaces "github.com/deepgram/deepgram-go-sdk/pkg/client/interfaces"
+)
+
+// setOption sets the option of opts named key in the Deepgram API, like
+// profanity_filter, to value. List options, like keywords, take values
+// separated by commas and are added to when set more than once. It reports
+// whether key is an option the SDK knows.
+func setOption(opts *interfaces.PreRecordedTranscriptionOptions, key, value string) (bool, error) {
+	v := reflect.ValueOf(opts).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		if name != key {
+			continue
+		}
+
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return true, fmt.Errorf("option %s takes true or false, got %q", key, value)
+			}
+			field.SetBool(b)
+		case reflect.Int:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return true, fmt.Errorf("option %s takes a whole number, got %q", key, value)
+			}
+			field.SetInt(int64(n))
+		case reflect.Float64:
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return true, fmt.Errorf("option %s takes a number, got %q", key, value)
+			}
+			field.SetFloat(f)
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Slice:
+			field.Set(reflect.AppendSlice(field, reflect.ValueOf(strings.Split(value, ","))))
+		default:
+			return true, fmt.Errorf("option %s can't be set with --opt", key)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// applyOptions sets the options given with --opt on opts, skipping the ones
+// checkOptions warned about.
+func applyOptions(opts *interfaces.PreRecordedTranscriptionOptions) {
+	for _, opt := range cfg.GetStringSlice("opt") {
+		key, value, _ := strings.Cut(opt, "=")
+		setOption(opts, key, value)
+	}
+}
+
+// checkOptions checks that every --opt is a key=value pair with a valid value,
+// and warns about the keys the SDK doesn't know, which are ignored.
+func checkOptions() error {
+	for _, opt := range cfg.GetStringSlice("opt") {
+		key, value, ok := strings.Cut(opt, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("--opt takes key=value, like numerals=true, got %q", opt)
+		}
+
+		known, err := setOption(&interfaces.PreRecordedTranscriptionOptions{}, key, value)
+		if err != nil {
+			return err
+		}
+		if !known {
+			logf("Warning: unknown Deepgram option %q, ignoring it\n", key)
+		}
+	}
+	return nil
+}
+
+// checkKeywords checks that every --keyword is a term, optionally followed by
+// how much to boost it, like kubernetes:2.
+func checkKeywords() error {
+	for _, k := range cfg.GetStringSlice("keyword") {
+		term, boost, ok := strings.Cut(k, ":")
+		if strings.TrimSpace(term) == "" {
+			return fmt.Errorf("--keyword takes term:boost, like kubernetes:2, got %q", k)
+		}
+		if _, err := strconv.ParseFloat(boost, 64); ok && err != nil {
+			return fmt.Errorf("the boost of --keyword %q must be a number, like %s:2", k, term)
+		}
+	}
+	return nil
+}
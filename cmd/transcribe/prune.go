@@ -0,0 +1,137 @@
+package transcribe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// audioNameRe matches the names audioName gives extracted audio, without
+// their extension.
+var audioNameRe = regexp.MustCompile(`^.+_[0-9a-f]{8}$`)
+
+// audioName returns the name, without extension, of the audio extracted from
+// f: its base name and a short hash of its absolute path and modification
+// time, so different sources with the same name, or a source replaced since,
+// never share extracted audio.
+func (f FilePath) audioName() string {
+	key, err := filepath.Abs(string(f))
+	if err != nil {
+		key = string(f)
+	}
+	if info, err := os.Stat(string(f)); err == nil {
+		key += "\x00" + strconv.FormatInt(info.ModTime().UnixNano(), 10)
+	}
+	h := sha256.Sum256([]byte(key))
+	return f.Base() + "_" + hex.EncodeToString(h[:])[:urlHashLength]
+}
+
+// PruneAudio removes from the audio directories of files the audio extracted
+// from sources that are gone or changed since: audio named like the audio
+// dgram extracts that doesn't belong to any media file as it is now in the
+// directory of its source. Audio extracted before it was named by source,
+// <base>.mp3, of the media files in that directory is removed too, as it's
+// never used again. Other files in the audio directories are left alone. With
+// dryRun the files are only listed.
+func PruneAudio(files []string, dryRun bool) error {
+	err := checkDirName("audio-dir-name")
+	if err != nil {
+		return err
+	}
+
+	// the directory of the sources of each audio directory
+	sources := make(map[string]string)
+	var dirs []string
+	for _, file := range files {
+		if isURL(file) {
+			continue
+		}
+		fp := FilePath(file)
+		dir := audioDir(fp)
+		if _, ok := sources[dir]; !ok {
+			sources[dir] = fp.Dir()
+			dirs = append(dirs, dir)
+		}
+	}
+
+	var pruned int
+	var size int64
+	for _, dir := range dirs {
+		keep, legacy, err := sourceAudioNames(sources[dir])
+		if err != nil {
+			return err
+		}
+
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("reading audio directory %q: %w", dir, err)
+		}
+
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			name := strings.TrimSuffix(entry.Name(), ext)
+			if entry.IsDir() || !slices.Contains(AudioExtensions, ext) {
+				continue
+			}
+			if !legacy[name] && (!audioNameRe.MatchString(name) || keep[name]) {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			info, err := entry.Info()
+			if err != nil {
+				return fmt.Errorf("getting info of %q: %w", path, err)
+			}
+
+			if dryRun {
+				infof("Would remove %q\n", path)
+			} else {
+				err = os.Remove(path)
+				if err != nil {
+					return fmt.Errorf("removing %q: %w", path, err)
+				}
+				infof("Removed %q\n", path)
+			}
+			pruned++
+			size += info.Size()
+		}
+	}
+
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+	infof("%s %d orphaned audio files, %s\n", verb, pruned, formatSize(size))
+	return nil
+}
+
+// sourceAudioNames returns the names audio extracted from the media files in
+// dir gets, see audioName, and the names it got before, their base names.
+func sourceAudioNames(dir string) (names, legacy map[string]bool, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading source directory %q: %w", dir, err)
+	}
+
+	names = make(map[string]bool)
+	legacy = make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() || !isMedia(entry.Name()) {
+			continue
+		}
+		fp := FilePath(filepath.Join(dir, entry.Name()))
+		names[fp.audioName()] = true
+		legacy[fp.Base()] = true
+	}
+	return names, legacy, nil
+}
@@ -0,0 +1,59 @@
+package transcribe
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	interfaces "github.com/deepgram/deepgram-go-sdk/pkg/client/interfaces"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+// unsupportedMediaRe matches the messages Deepgram rejects uploads with when
+// it can't decode their audio.
+var unsupportedMediaRe = regexp.MustCompile(`(?i)unsupported (data|media|format)|corrupt`)
+
+// isUnsupportedMedia reports whether err is Deepgram rejecting the audio it
+// was sent because it can't decode it.
+func isUnsupportedMedia(err error) bool {
+	var e *interfaces.StatusError
+	if !errors.As(err, &e) {
+		return false
+	}
+	if e.Resp != nil && e.Resp.StatusCode == http.StatusUnsupportedMediaType {
+		return true
+	}
+	return e.DeepgramError != nil && unsupportedMediaRe.MatchString(e.DeepgramError.ErrMsg)
+}
+
+// convertAudio re-encodes the audio of file with ffmpeg into mono WAV, which
+// Deepgram always accepts, at --audio-sample-rate.
+func convertAudio(file FilePath) (FilePath, error) {
+	dir := audioDir(file)
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return "", fmt.Errorf("creating audio directory %q: %w", dir, err)
+	}
+
+	audioPath := FilePath(filepath.Join(dir, file.audioName()+".wav"))
+	args := ffmpeg.KwArgs{"vn": "", "ac": 1}
+	if rate := cfg.GetInt("audio-sample-rate"); rate > 0 {
+		args["ar"] = rate
+	}
+
+	infof("Converting %q to %q\n", file, audioPath)
+	err = ffmpeg.
+		Input(string(file)).
+		Output(string(audioPath), args).
+		OverWriteOutput().
+		Silent(true).
+		Run()
+	if err != nil {
+		return "", fmt.Errorf("running ffmpeg converting %q to %q: %w", file, audioPath, err)
+	}
+
+	return audioPath, nil
+}
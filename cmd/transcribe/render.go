@@ -0,0 +1,434 @@
+package transcribe
+
+import (
+	"context"
+	"dgram/lib/captions"
+	"dgram/lib/fsys"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andrerfcsantos/deepgram-go-captions/converters"
+	"github.com/andrerfcsantos/deepgram-go-captions/renderers"
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+	"github.com/spf13/pflag"
+)
+
+// AddRenderFlags registers the flags that control how captions are rendered
+// from a transcript, for every command that renders them.
+func AddRenderFlags(flags *pflag.FlagSet) {
+	flags.String("output-dir", "", "directory where the outputs are written instead of next to each input, mirroring the structure of the inputs, with the summaries of the run at its root")
+	flags.Duration("pause-split", 0, "start a new caption whenever the pause between words is longer than this (--pause-split alone uses 500ms, use --pause-split=1s to change it)")
+	flags.Lookup("pause-split").NoOptDefVal = "500ms"
+	flags.Duration("time-precision", 0, "round caption timestamps to the nearest multiple of this, like 10ms (0 keeps Deepgram's timestamps)")
+	flags.Float64("fps", 0, "frame rate of the video, like 25 or 29.97: caption timestamps snap to its frames (overriding --time-precision) and EDL timecodes count them")
+	flags.StringSlice("format", []string{"srt"}, "caption formats to write, separated by commas (srt, vtt, both, txt, tsv, minutes, md, edl, ass, json)")
+	flags.Bool("minutes", false, "also write a meeting-minutes style transcript, one timestamped line per speaker turn")
+	flags.Bool("tsv", false, "also write a .tsv with the start, end and text of each paragraph, separated by tabs, for chapter markers")
+	flags.Bool("edl", false, "also write an EDL with a marker per segment of the transcript, to import in video editors")
+	flags.String("edl-granularity", "utterance", "segments marked in the EDL (utterance, paragraph, topic)")
+	flags.Bool("diarize", true, "tell the speakers apart and label them, use --diarize=false for single-speaker recordings")
+	flags.StringToString("speaker-names", nil, "names for the diarized speakers, like 0=Alice,1=Bob (C0-S1=Alice for speaker 1 of channel 0 with --multichannel)")
+	flags.Bool("split-speakers", false, "also write a .speakerN.txt per diarized speaker, with only the turns of that speaker")
+	flags.StringSlice("clip-around", nil, "also write a .clips.srt with only the captions around these keywords, and the list of their time ranges")
+	flags.Duration("window", 10*time.Second, "how much before and after each keyword --clip-around keeps")
+	flags.Bool("extract-clips", false, "cut the ranges found by --clip-around out of the source with ffmpeg, into <name>_clip_N files in .clips")
+	flags.Float64("flag-low-confidence", 0, "mark the words Deepgram is less confident about than this, from 0 to 1, like ?word?, in the captions and the txt format")
+	flags.Float64("min-confidence", 0, "leave out of the txt and tsv formats the words Deepgram is less confident about than this, from 0 to 1 (other formats keep every word)")
+	flags.String("replace-file", "", "file of regex substitutions applied in order to the rendered captions, one /pattern/replacement/flags per line")
+	flags.Int("max-line-length", 0, "wrap the text of captions into lines of at most this many characters, starting a new caption when they don't fit in --max-lines (0 keeps a line per caption)")
+	flags.Int("max-lines", 2, "maximum number of lines per caption when --max-line-length wraps them")
+	flags.String("name-template", "", "name of the outputs of each file, with the placeholders {base}, {dir}, {lang}, {model} and {date}, like {base}_{lang}_{model}, the cached transcripts keep {base} (default {base})")
+	flags.Int("alternative", 0, "index of the alternative transcript the outputs and WPM use, when more than one was requested with --opt alternatives=N")
+	flags.Int("preview", 0, "print the first N captions of each file after rendering it")
+}
+
+// newConverter builds the caption converter for r according to the rendering
+// flags.
+func newConverter(r *interfacesv1.PreRecordedResponse) converters.Converter {
+	var conv converters.Converter
+	maxLength := cfg.GetInt("max-line-length")
+	if pause := cfg.GetDuration("pause-split"); pause > 0 {
+		// let the pauses decide where cues break instead of a fixed word count
+		conv = captions.PauseSplitter{
+			Converter: converters.NewDeepgramConverter(r, converters.WithLineLength(math.MaxInt)),
+			Threshold: pause.Seconds(),
+		}
+	} else if maxLength > 0 {
+		// the length of the lines decides where cues break instead
+		conv = converters.NewDeepgramConverter(r, converters.WithLineLength(math.MaxInt))
+	} else {
+		conv = converters.NewDeepgramConverter(r)
+	}
+
+	if fps := cfg.GetFloat64("fps"); fps > 0 {
+		// snapping to frames is stricter than any --time-precision
+		conv = captions.Rounder{Converter: conv, Step: 1 / fps}
+	} else if precision := cfg.GetDuration("time-precision"); precision > 0 {
+		conv = captions.Rounder{Converter: conv, Step: precision.Seconds()}
+	}
+
+	if threshold := cfg.GetFloat64("flag-low-confidence"); threshold > 0 {
+		conv = captions.LowConfidence{Converter: conv, Threshold: threshold}
+	}
+
+	// wrap last, so the length of the lines counts the marks of the other
+	// converters
+	if maxLength > 0 {
+		conv = captions.LineWrapper{Converter: conv, MaxLength: maxLength, MaxLines: cfg.GetInt("max-lines")}
+	}
+	return conv
+}
+
+// captionFormat is a caption format that can be selected with --format.
+type captionFormat struct {
+	ext    string
+	render func(*interfacesv1.PreRecordedResponse) (string, error)
+}
+
+var captionFormats = map[string]captionFormat{
+	"srt":     {ext: ".srt", render: withConverter(renderers.SRT)},
+	"vtt":     {ext: ".vtt", render: withConverter(renderers.WebVTT)},
+	"minutes": {ext: ".minutes.txt", render: renderMinutes},
+	"md":      {ext: ".md", render: renderMarkdown},
+	"txt":     {ext: ".txt", render: renderText},
+	"tsv":     {ext: ".tsv", render: renderTSV},
+	"edl":     {ext: ".edl", render: renderEDL},
+	"ass":     {ext: ".ass", render: renderASS},
+	"json":    {ext: ".json", render: renderJSON},
+}
+
+// withConverter adapts a renderer of the captions library to render a
+// transcript through newConverter. The speakers of multichannel transcripts
+// are labeled after their channel, see channelLabel.
+func withConverter(render func(converters.Converter) (string, error)) func(*interfacesv1.PreRecordedResponse) (string, error) {
+	return func(r *interfacesv1.PreRecordedResponse) (string, error) {
+		r = diarized(r)
+		if !captions.Multichannel(r) {
+			out, err := render(newConverter(r))
+			return captions.TrimWrapped(out), err
+		}
+
+		out, err := render(newConverter(captions.ChannelSpeakers(r)))
+		if err != nil {
+			return "", err
+		}
+		return captions.RelabelSpeakers(captions.TrimWrapped(out), channelLabel), nil
+	}
+}
+
+func renderMinutes(r *interfacesv1.PreRecordedResponse) (string, error) {
+	return captions.Minutes(labeledSpeakers(r)), nil
+}
+
+func renderText(r *interfacesv1.PreRecordedResponse) (string, error) {
+	r, label := labeledSpeakers(r)
+	r = confident(r)
+	if threshold := cfg.GetFloat64("flag-low-confidence"); threshold > 0 {
+		r = captions.MarkLowConfidence(r, threshold)
+	}
+	return captions.Text(r, label), nil
+}
+
+func renderTSV(r *interfacesv1.PreRecordedResponse) (string, error) {
+	r, _ = labeledSpeakers(r)
+	return captions.TSV(confident(r)), nil
+}
+
+// confident returns r without the words below --min-confidence, for the
+// formats that don't need every word to keep their timing.
+func confident(r *interfacesv1.PreRecordedResponse) *interfacesv1.PreRecordedResponse {
+	if threshold := cfg.GetFloat64("min-confidence"); threshold > 0 {
+		return captions.WithoutLowConfidence(r, threshold)
+	}
+	return r
+}
+
+func renderASS(r *interfacesv1.PreRecordedResponse) (string, error) {
+	return captions.ASS(labeledSpeakers(r)), nil
+}
+
+// renderJSON renders the Deepgram response itself, for --stdout to pipe it.
+func renderJSON(r *interfacesv1.PreRecordedResponse) (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshaling response: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+func renderMarkdown(r *interfacesv1.PreRecordedResponse) (string, error) {
+	return captions.Markdown(labeledSpeakers(r)), nil
+}
+
+// defaultEDLFps is the frame rate of EDL timecodes when --fps isn't given.
+const defaultEDLFps = 30
+
+func renderEDL(r *interfacesv1.PreRecordedResponse) (string, error) {
+	r, label := labeledSpeakers(r)
+	markers, err := captions.Markers(r, cfg.GetString("edl-granularity"), label)
+	if err != nil {
+		return "", err
+	}
+
+	fps := cfg.GetFloat64("fps")
+	if fps <= 0 {
+		fps = defaultEDLFps
+	}
+	return captions.EDL("Transcript markers", markers, fps), nil
+}
+
+// diarized returns r, or r without its speakers when --diarize is off, so
+// transcripts cached with speakers are rendered without them too.
+func diarized(r *interfacesv1.PreRecordedResponse) *interfacesv1.PreRecordedResponse {
+	if cfg.GetBool("diarize") {
+		return r
+	}
+	return captions.WithoutSpeakers(r)
+}
+
+// labeledSpeakers returns the transcript to render the speaker turns of r
+// from, along with how to name its speakers.
+func labeledSpeakers(r *interfacesv1.PreRecordedResponse) (*interfacesv1.PreRecordedResponse, func(int) string) {
+	r = diarized(r)
+	if captions.Multichannel(r) {
+		return captions.ChannelSpeakers(r), channelLabel
+	}
+	return r, speakerLabel
+}
+
+// speakerLabel returns the name given to speaker with --speaker-names, or
+// "Speaker N" if it wasn't named.
+func speakerLabel(speaker int) string {
+	if name, ok := cfg.GetStringMapString("speaker-names")[strconv.Itoa(speaker)]; ok {
+		return name
+	}
+	return fmt.Sprintf("Speaker %d", speaker)
+}
+
+// channelLabel is speakerLabel for the speakers of multichannel transcripts.
+// Deepgram numbers the speakers of each channel separately, so these are
+// labeled "C<channel>-S<speaker>", like C0-S1 for speaker 1 of channel 0, and
+// are named with --speaker-names C0-S1=Alice.
+func channelLabel(speaker int) string {
+	label := captions.ChannelLabel(speaker)
+	if name, ok := cfg.GetStringMapString("speaker-names")[label]; ok {
+		return name
+	}
+	return label
+}
+
+// formats returns the caption formats selected with --format and the flags
+// that add a format.
+func formats() []string {
+	var selected []string
+	for _, f := range cfg.GetStringSlice("format") {
+		// both is short for the two subtitle formats
+		if f == "both" {
+			selected = append(selected, "srt", "vtt")
+			continue
+		}
+		if !slices.Contains(selected, f) {
+			selected = append(selected, f)
+		}
+	}
+	if cfg.GetBool("minutes") && !slices.Contains(selected, "minutes") {
+		selected = append(selected, "minutes")
+	}
+	if cfg.GetBool("tsv") && !slices.Contains(selected, "tsv") {
+		selected = append(selected, "tsv")
+	}
+	if cfg.GetBool("edl") && !slices.Contains(selected, "edl") {
+		selected = append(selected, "edl")
+	}
+	return selected
+}
+
+// ValidateRenderFlags checks the rendering flags, like every format given to
+// --format being supported, and loads the rules of --replace-file.
+func ValidateRenderFlags() error {
+	if !slices.Contains(captions.Granularities, cfg.GetString("edl-granularity")) {
+		return fmt.Errorf("unsupported --edl-granularity %q, supported granularities are: %s", cfg.GetString("edl-granularity"), strings.Join(captions.Granularities, ", "))
+	}
+	if n := cfg.GetInt("max-line-length"); n < 0 {
+		return fmt.Errorf("--max-line-length can't be negative, got %d", n)
+	}
+	if n := cfg.GetInt("max-lines"); n < 1 {
+		return fmt.Errorf("--max-lines must be at least 1, got %d", n)
+	}
+	if c := cfg.GetFloat64("flag-low-confidence"); c < 0 || c > 1 {
+		return fmt.Errorf("--flag-low-confidence must be between 0 and 1, got %v", c)
+	}
+	if c := cfg.GetFloat64("min-confidence"); c < 0 || c > 1 {
+		return fmt.Errorf("--min-confidence must be between 0 and 1, got %v", c)
+	}
+	if n := cfg.GetInt("alternative"); n < 0 {
+		return fmt.Errorf("--alternative can't be negative, got %d", n)
+	}
+	if cfg.GetBool("extract-clips") && len(cfg.GetStringSlice("clip-around")) == 0 {
+		return fmt.Errorf("--extract-clips needs the keywords to cut clips around, given with --clip-around")
+	}
+	err := checkNameTemplate()
+	if err != nil {
+		return err
+	}
+	for _, f := range formats() {
+		if _, ok := captionFormats[f]; !ok {
+			return fmt.Errorf("unsupported format %q, supported formats are: %s", f, strings.Join(slices.Sorted(maps.Keys(captionFormats)), ", "))
+		}
+	}
+	return loadReplacements()
+}
+
+// SelectAlternative returns r with the alternative chosen with --alternative
+// first. When r doesn't have it, it warns and returns r as it is, with the
+// first alternative.
+func SelectAlternative(ctx context.Context, r *interfacesv1.PreRecordedResponse, file FilePath) *interfacesv1.PreRecordedResponse {
+	n := cfg.GetInt("alternative")
+	chosen, ok := captions.WithAlternative(r, n)
+	if !ok {
+		warnf(ctx, "Warning: the transcript of %q has no alternative %d, using alternative 0\n", file, n)
+	}
+	return chosen
+}
+
+// captionPath returns the path of the captions of file in the given format.
+func captionPath(file FilePath, format string) string {
+	return filepath.Join(file.OutputDir(), file.OutputName()+captionFormats[format].ext)
+}
+
+// srtPath returns the path of the SRT file for file.
+func srtPath(file FilePath) string {
+	return captionPath(file, "srt")
+}
+
+// renderCaptions renders the captions of r in the given format, with the
+// rules of --replace-file applied. It also returns how many substitutions each
+// rule made.
+func renderCaptions(r *interfacesv1.PreRecordedResponse, format string) (string, []int, error) {
+	out, err := captionFormats[format].render(r)
+	if err != nil {
+		return "", nil, fmt.Errorf("rendering %s: %w", strings.ToUpper(format), err)
+	}
+	out, counts := replace(out)
+	return out, counts, nil
+}
+
+// WriteCaptions renders the captions of r to a file next to file for each of
+// the selected formats. Existing caption files are only replaced when
+// overwrite is set.
+func WriteCaptions(ctx context.Context, r *interfacesv1.PreRecordedResponse, file FilePath, overwrite bool) error {
+	err := os.MkdirAll(file.OutputDir(), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("creating output directory %q: %w", file.OutputDir(), err)
+	}
+
+	for _, format := range formats() {
+		path := captionPath(file, format)
+
+		if !overwrite && fsys.FileExists(path) {
+			infof("%s file %q already exists, skipping\n", strings.ToUpper(format), path)
+			continue
+		}
+
+		out, counts, err := renderCaptions(r, format)
+		if err != nil {
+			return fmt.Errorf("rendering captions for %s: %w", file, err)
+		}
+
+		err = os.WriteFile(path, []byte(out), 0644)
+		if err != nil {
+			return fmt.Errorf("writing %s file %q: %w", strings.ToUpper(format), path, err)
+		}
+		logReplacements(path, counts)
+		logEvent(ctx, "captions_written", file, "format", format, "path", path)
+	}
+
+	if cfg.GetBool("split-speakers") {
+		err := writeSpeakers(r, file, overwrite)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(cfg.GetStringSlice("clip-around")) > 0 {
+		err := writeClips(r, file, overwrite)
+		if err != nil {
+			return err
+		}
+		if cfg.GetBool("extract-clips") {
+			return extractClips(file, keywordRanges(r), overwrite)
+		}
+	}
+	return nil
+}
+
+// PreviewSRT prints the first n captions of the SRT file of file, if --preview
+// asks for them.
+func PreviewSRT(file FilePath) {
+	n := cfg.GetInt("preview")
+	if n <= 0 || !slices.Contains(formats(), "srt") {
+		return
+	}
+
+	path := srtPath(file)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logf("Can't preview %q: %v\n", path, err)
+		return
+	}
+
+	cues := strings.Split(strings.TrimSpace(string(data)), "\n\n")
+	if len(cues) > n {
+		cues = cues[:n]
+	}
+	logf("Preview of %q:\n%s\n\n", path, strings.Join(cues, "\n\n"))
+}
+
+// Render writes the captions of the cached transcripts of files again,
+// without calling Deepgram. Files without a transcript are skipped.
+func Render(ctx context.Context, files []string) error {
+	applyQuiet()
+
+	var errs []error
+	for _, file := range files {
+		fp := FilePath(file)
+
+		r, err := LoadTranscript(fp)
+		if errors.Is(err, fs.ErrNotExist) {
+			infof("No transcript found for %q, skipping\n", file)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("loading transcript for %q: %w", file, err))
+			continue
+		}
+
+		r = SelectAlternative(ctx, r, fp)
+		err = WriteCaptions(ctx, r, fp, true)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		infof("Rendered captions for %q\n", file)
+		PreviewSRT(fp)
+	}
+
+	if len(errs) > 0 {
+		logf("Some errors occurred during rendering these files:\n")
+		for _, e := range errs {
+			logf("  - %v\n", e)
+		}
+		return fmt.Errorf("%d of %d files couldn't be rendered", len(errs), len(files))
+	}
+	return nil
+}
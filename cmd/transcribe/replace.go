@@ -0,0 +1,151 @@
+package transcribe
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// replacement is a rule of the --replace-file, written /pattern/replacement/flags.
+type replacement struct {
+	rule        string
+	re          *regexp.Regexp
+	replacement string
+	global      bool
+}
+
+// replacements are the rules loaded from --replace-file by loadReplacements.
+var replacements []replacement
+
+// backrefRe matches the \1 style references to capture groups in
+// replacements, which Go writes as ${1}.
+var backrefRe = regexp.MustCompile(`\\(\d+)`)
+
+// loadReplacements reads and checks the rules of --replace-file. Each line is
+// a rule like /pattern/replacement/flags, where the flags can be g to replace
+// every match instead of only the first, and i, m or s as in Go regular
+// expressions. Empty lines and lines starting with # are ignored.
+func loadReplacements() error {
+	replacements = nil
+	path := cfg.GetString("replace-file")
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading replace file: %w", err)
+	}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		r, err := parseReplacement(line)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+		replacements = append(replacements, r)
+	}
+	return nil
+}
+
+func parseReplacement(rule string) (replacement, error) {
+	parts := splitRule(rule)
+	if len(parts) != 3 || parts[0] != "" {
+		return replacement{}, fmt.Errorf("rule %q isn't like /pattern/replacement/flags", rule)
+	}
+	pattern, repl, flags := parts[1], parts[2], ""
+	if i := strings.LastIndex(rule, "/"); i >= 0 {
+		flags = rule[i+1:]
+	}
+
+	// a $ is literal in rules, only \N refers to a group
+	repl = strings.ReplaceAll(repl, "$", "$$")
+	r := replacement{rule: rule, replacement: backrefRe.ReplaceAllString(repl, "$${$1}")}
+	var inline string
+	for _, f := range flags {
+		switch f {
+		case 'g':
+			r.global = true
+		case 'i', 'm', 's':
+			inline += string(f)
+		default:
+			return replacement{}, fmt.Errorf("rule %q has unknown flag %q", rule, f)
+		}
+	}
+	if inline != "" {
+		pattern = "(?" + inline + ")" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return replacement{}, fmt.Errorf("rule %q: %w", rule, err)
+	}
+	r.re = re
+	return r, nil
+}
+
+// splitRule splits rule on the slashes that aren't escaped with a backslash,
+// leaving out the flags after the last one. Escaped slashes are unescaped.
+func splitRule(rule string) []string {
+	var parts []string
+	var current strings.Builder
+	for i := 0; i < len(rule); i++ {
+		switch {
+		case rule[i] == '\\' && i+1 < len(rule) && rule[i+1] == '/':
+			current.WriteByte('/')
+			i++
+		case rule[i] == '/':
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(rule[i])
+		}
+	}
+	return parts
+}
+
+// replace applies the rules of --replace-file to text, in order. It returns
+// the new text and how many substitutions each rule made.
+func replace(text string) (string, []int) {
+	counts := make([]int, len(replacements))
+	for i, r := range replacements {
+		matches := r.re.FindAllStringSubmatchIndex(text, -1)
+		if !r.global && len(matches) > 1 {
+			matches = matches[:1]
+		}
+		counts[i] = len(matches)
+		if len(matches) == 0 {
+			continue
+		}
+
+		var b strings.Builder
+		last := 0
+		for _, m := range matches {
+			b.WriteString(text[last:m[0]])
+			b.Write(r.re.ExpandString(nil, r.replacement, text, m))
+			last = m[1]
+		}
+		b.WriteString(text[last:])
+		text = b.String()
+	}
+	return text, counts
+}
+
+// logReplacements logs how many substitutions each rule of --replace-file
+// made in the captions written to path.
+func logReplacements(path string, counts []int) {
+	if len(replacements) == 0 {
+		return
+	}
+
+	made := make([]string, len(counts))
+	for i, n := range counts {
+		made[i] = fmt.Sprintf("%s: %d", replacements[i].rule, n)
+	}
+	infof("Replacements in %q: %s\n", path, strings.Join(made, ", "))
+}
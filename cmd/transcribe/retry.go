@@ -0,0 +1,101 @@
+package transcribe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	api "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest"
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+	interfaces "github.com/deepgram/deepgram-go-sdk/pkg/client/interfaces"
+)
+
+// firstBackoff is how long the first retry of a request waits, each retry
+// after it waits twice as long as the one before.
+var firstBackoff = time.Second
+
+// retryableStatuses are the statuses of Deepgram errors that may go away by
+// themselves, like rate limits or overloaded servers.
+var retryableStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// fromFile sends audio to Deepgram, or its URL for remote files, retrying up
+// to --retries times with exponential backoff when Deepgram fails with a
+// retryable status. The waits between attempts are traced as "backoff".
+func fromFile(ctx context.Context, dg *api.Client, audio FilePath) (*interfacesv1.PreRecordedResponse, error) {
+	retries := cfg.GetInt("retries")
+	backoff := firstBackoff
+	for attempt := 0; ; attempt++ {
+		var res *interfacesv1.PreRecordedResponse
+		var err error
+		requestCtx, end := traceRequest(ctx)
+		if isURL(string(audio)) {
+			res, err = dg.FromURL(requestCtx, string(audio), transcriptionOptions())
+		} else {
+			res, err = dg.FromFile(requestCtx, string(audio), transcriptionOptions())
+		}
+		end()
+		if err == nil || attempt >= retries || !isRetryable(err) {
+			return res, err
+		}
+
+		wait := max(backoff, retryAfter(err))
+		infof("Deepgram failed transcribing %q (%v), retrying in %v (%d/%d)\n", audio, err, wait, attempt+1, retries)
+		end = startStage(ctx, "backoff")
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			end()
+			return nil, ctx.Err()
+		}
+		end()
+		backoff *= 2
+	}
+}
+
+// deepgramError describes err, an error of a request to Deepgram, with the
+// code and message Deepgram gave when it gave any. Deepgram only gives them
+// for some statuses, for the others the status is all there is.
+func deepgramError(err error) error {
+	var e *interfaces.StatusError
+	if !errors.As(err, &e) {
+		return fmt.Errorf("getting response from deepgram: %w", err)
+	}
+	if e.DeepgramError != nil {
+		return fmt.Errorf("deepgram status error (%s) %s", e.DeepgramError.ErrCode, e.DeepgramError.ErrMsg)
+	}
+	if e.Resp != nil {
+		return fmt.Errorf("deepgram status error %s", e.Resp.Status)
+	}
+	// without a response the SDK can't describe the error either
+	return errors.New("deepgram status error without a response")
+}
+
+// isRetryable reports whether err is a Deepgram error worth trying again.
+func isRetryable(err error) bool {
+	var e *interfaces.StatusError
+	return errors.As(err, &e) && e.Resp != nil && slices.Contains(retryableStatuses, e.Resp.StatusCode)
+}
+
+// retryAfter returns how long the Retry-After header of a Deepgram error asks
+// to wait, or 0 if it has none.
+func retryAfter(err error) time.Duration {
+	var e *interfaces.StatusError
+	if !errors.As(err, &e) || e.Resp == nil {
+		return 0
+	}
+	seconds, convErr := strconv.Atoi(e.Resp.Header.Get("Retry-After"))
+	if convErr != nil {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,92 @@
+package transcribe
+
+import (
+	"context"
+	"dgram/lib/config"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	api "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest"
+	interfaces "github.com/deepgram/deepgram-go-sdk/pkg/client/interfaces"
+	client "github.com/deepgram/deepgram-go-sdk/pkg/client/listen"
+)
+
+func TestFromFileRetriesUnavailable(t *testing.T) {
+	cfg = config.NewConfig("dgram")
+	cfg.Set("retries", 2)
+	defer func(b time.Duration) { firstBackoff = b }(firstBackoff)
+	firstBackoff = time.Millisecond
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	audio := filepath.Join(t.TempDir(), "talk.mp3")
+	err := os.WriteFile(audio, []byte("not really audio"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := client.NewREST("key", &interfaces.ClientOptions{APIKey: "key", Host: srv.URL})
+	_, err = fromFile(context.Background(), api.New(c), FilePath(audio))
+	if err == nil {
+		t.Fatal("fromFile succeeded, want an error")
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("got %d requests, want 3", n)
+	}
+
+	got := deepgramError(err).Error()
+	if !strings.Contains(got, "503") {
+		t.Errorf("deepgramError() = %q, want the 503 status in it", got)
+	}
+}
+
+func TestFromFileTracesBackoff(t *testing.T) {
+	cfg = config.NewConfig("dgram")
+	cfg.Set("retries", 1)
+	defer func(b time.Duration) { firstBackoff = b }(firstBackoff)
+	firstBackoff = 50 * time.Millisecond
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	audio := filepath.Join(t.TempDir(), "talk.mp3")
+	err := os.WriteFile(audio, []byte("not really audio"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, tr := withTrace(context.Background())
+	c := client.NewREST("key", &interfaces.ClientOptions{APIKey: "key", Host: srv.URL})
+	_, err = fromFile(ctx, api.New(c), FilePath(audio))
+	if err == nil {
+		t.Fatal("fromFile succeeded, want an error")
+	}
+
+	var backoff float64
+	for _, s := range tr.Stages {
+		switch s.Stage {
+		case "backoff":
+			backoff += s.Seconds
+		case "download":
+			if s.Seconds >= firstBackoff.Seconds() {
+				t.Errorf("download took %vs, want the backoff left out of it", s.Seconds)
+			}
+		}
+	}
+	if backoff < firstBackoff.Seconds() {
+		t.Errorf("backoff took %vs, want at least %v", backoff, firstBackoff)
+	}
+}
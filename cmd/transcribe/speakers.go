@@ -0,0 +1,54 @@
+package transcribe
+
+import (
+	"dgram/lib/captions"
+	"dgram/lib/fsys"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+)
+
+// speakerPath returns the path of the transcript with only the turns of the
+// speaker labeled label in file, like talk.speaker0.txt.
+func speakerPath(file FilePath, label string) string {
+	return filepath.Join(file.OutputDir(), file.OutputName()+".speaker"+label+".txt")
+}
+
+// writeSpeakers writes, for --split-speakers, a transcript per speaker of r
+// with only the turns of that speaker, one per line. The speakers of
+// multichannel transcripts are labeled after their channel, like
+// talk.speakerC0-S1.txt. Existing files are only replaced when overwrite is
+// set.
+func writeSpeakers(r *interfacesv1.PreRecordedResponse, file FilePath, overwrite bool) error {
+	label := strconv.Itoa
+	if captions.Multichannel(r) {
+		label = captions.ChannelLabel
+	}
+	r, _ = labeledSpeakers(r)
+
+	turns := captions.SpeakerTranscripts(r)
+	if len(turns) == 0 {
+		infof("No speakers in the transcript of %q to split, it wasn't diarized\n", file)
+		return nil
+	}
+
+	for _, speaker := range slices.Sorted(maps.Keys(turns)) {
+		path := speakerPath(file, label(speaker))
+		if !overwrite && fsys.FileExists(path) {
+			infof("Speaker file %q already exists, skipping\n", path)
+			continue
+		}
+
+		err := os.WriteFile(path, []byte(strings.Join(turns[speaker], "\n")+"\n"), 0644)
+		if err != nil {
+			return fmt.Errorf("writing speaker file %q: %w", path, err)
+		}
+	}
+	return nil
+}
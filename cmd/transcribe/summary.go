@@ -0,0 +1,70 @@
+package transcribe
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/spf13/pflag"
+)
+
+// AddSummaryFlags registers the flags that control the summary of a run, for
+// every command that writes it.
+func AddSummaryFlags(flags *pflag.FlagSet) {
+	flags.Bool("csv", false, "also write wpms.csv with the words per minute, duration and word count of each file")
+	flags.String("summary-file", "wpms.json", "name of the summary with the words per minute of each file, in the order they were done, relative to --output-dir unless absolute")
+}
+
+// Summarize writes the summary of the cached transcripts of files, the same
+// transcribe writes, without calling Deepgram. Files without a transcript are
+// skipped.
+func Summarize(files []string) error {
+	err := createSummaryDirs()
+	if err != nil {
+		return err
+	}
+	summary, err := createSummary()
+	if err != nil {
+		return err
+	}
+
+	var warned []FileResult
+	var errs []error
+	for _, file := range files {
+		r, err := LoadTranscript(FilePath(file))
+		if errors.Is(err, fs.ErrNotExist) {
+			infof("No transcript found for %q, skipping\n", file)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("loading transcript for %q: %w", file, err))
+			continue
+		}
+
+		result := newFileResult(file, r)
+		err = summary.write(result)
+		if err != nil {
+			summary.Close()
+			return err
+		}
+		if len(result.Warnings) > 0 {
+			warned = append(warned, result)
+		}
+	}
+
+	err = summary.Close()
+	if err != nil {
+		return err
+	}
+	infof("Summarized %d files into %q\n", summary.n, summaryPath(cfg.GetString("summary-file")))
+	logWarnings(warned)
+
+	if len(errs) > 0 {
+		logf("Some errors occurred during loading these transcripts:\n")
+		for _, e := range errs {
+			logf("  - %v\n", e)
+		}
+		return fmt.Errorf("%d of %d transcripts couldn't be loaded", len(errs), len(files))
+	}
+	return nil
+}
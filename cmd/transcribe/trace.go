@@ -0,0 +1,99 @@
+package transcribe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptrace"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// trace records how long each stage of processing a file took, for --trace.
+type trace struct {
+	mu     sync.Mutex
+	Stages []traceStage `json:"stages"`
+	Total  float64      `json:"total_seconds"`
+}
+
+type traceStage struct {
+	Stage   string  `json:"stage"`
+	Seconds float64 `json:"seconds"`
+}
+
+type traceKey struct{}
+
+// withTrace returns a copy of ctx that records the stages timed with
+// startStage into the returned trace.
+func withTrace(ctx context.Context) (context.Context, *trace) {
+	t := &trace{}
+	return context.WithValue(ctx, traceKey{}, t), t
+}
+
+// startStage starts timing the stage named name and returns the function that
+// ends it. It does nothing when ctx isn't traced.
+func startStage(ctx context.Context, name string) func() {
+	t, ok := ctx.Value(traceKey{}).(*trace)
+	if !ok {
+		return func() {}
+	}
+
+	started := time.Now()
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.Stages = append(t.Stages, traceStage{Stage: name, Seconds: time.Since(started).Seconds()})
+	}
+}
+
+// traceRequest returns a copy of ctx that times the requests to Deepgram made
+// with it in three stages: "upload", until the request is sent, "processing",
+// until Deepgram starts answering, and "download", until the response is read,
+// which ends when the returned function is called. It returns ctx as it is when ctx isn't traced.
+func traceRequest(ctx context.Context) (context.Context, func()) {
+	if _, ok := ctx.Value(traceKey{}).(*trace); !ok {
+		return ctx, func() {}
+	}
+
+	var mu sync.Mutex
+	end := func() {}
+	next := func(stage string) {
+		mu.Lock()
+		defer mu.Unlock()
+		end()
+		end = startStage(ctx, stage)
+	}
+	traced := httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn:              func(string) { next("upload") },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { next("processing") },
+		GotFirstResponseByte: func() { next("download") },
+	})
+	return traced, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		end()
+		end = func() {}
+	}
+}
+
+// writeTrace writes the trace t of file, which took total to process, to
+// <name>.trace.json next to file.
+func writeTrace(t *trace, file FilePath, total time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Total = total.Seconds()
+
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling trace: %w", err)
+	}
+
+	path := filepath.Join(file.OutputDir(), file.OutputName()+".trace.json")
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("writing trace file %q: %w", path, err)
+	}
+	return nil
+}
@@ -1,20 +1,27 @@
 package transcribe
 
 import (
+	"bufio"
 	"context"
+	"dgram/lib/captions"
+	"dgram/lib/chart"
 	"dgram/lib/config"
 	"dgram/lib/fsys"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"math"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"slices"
 	"strings"
-	"sync"
+	"syscall"
+	"time"
 
-	"github.com/andrerfcsantos/deepgram-go-captions/converters"
-	"github.com/andrerfcsantos/deepgram-go-captions/renderers"
 	api "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest"
 	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
 	interfaces "github.com/deepgram/deepgram-go-sdk/pkg/client/interfaces"
@@ -29,22 +36,58 @@ var (
 	cfg *config.Config
 )
 
+// Default names of the directories, next to each output, where the extracted
+// audio, the transcripts and the graphs go.
 const (
 	audioDirectory         = ".audio"
 	transcriptionDirectory = ".transcriptions"
 	graphsDirectory        = ".graphs"
 )
 
-func filesFromGlobs(globs []string) ([]string, error) {
-	files := make([]string, 0, 4)
-	for _, glob := range globs {
-		matches, err := filepath.Glob(glob)
-		if err != nil {
-			return nil, fmt.Errorf("globbing files with glob %q: %w", glob, err)
-		}
-		files = append(files, matches...)
+// dirNameFlags are the flags that rename the output directories.
+var dirNameFlags = []string{"audio-dir-name", "transcriptions-dir-name", "graphs-dir-name"}
+
+// checkDirName checks the directory name set by the flag or config key is
+// the name of a directory of its own, not a path that could point at the
+// inputs or outside of the output directory.
+func checkDirName(key string) error {
+	name := cfg.GetString(key)
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("--%s must be the name of a directory in the output directory, got %q", key, name)
 	}
-	return files, nil
+	return nil
+}
+
+// outputDirNames returns the names of the directories dgram writes to next
+// to the outputs, whose files are never inputs.
+func outputDirNames() []string {
+	return []string{
+		dirName("audio-dir-name", audioDirectory),
+		dirName("transcriptions-dir-name", transcriptionDirectory),
+		dirName("graphs-dir-name", graphsDirectory),
+		clipsDirectory,
+	}
+}
+
+// dirName returns the directory name set by the flag or config key, or
+// fallback when it isn't set, as for commands that don't have the flag.
+func dirName(key, fallback string) string {
+	if name := cfg.GetString(key); name != "" {
+		return name
+	}
+	return fallback
+}
+
+func audioDir(file FilePath) string {
+	return filepath.Join(file.OutputDir(), dirName("audio-dir-name", audioDirectory))
+}
+
+func transcriptionDir(file FilePath) string {
+	return filepath.Join(file.OutputDir(), dirName("transcriptions-dir-name", transcriptionDirectory))
+}
+
+func graphsDir(file FilePath) string {
+	return filepath.Join(file.OutputDir(), dirName("graphs-dir-name", graphsDirectory))
 }
 
 func getDgClient(apiKey string) (*api.Client, error) {
@@ -56,307 +99,1034 @@ func getDgClient(apiKey string) (*api.Client, error) {
 	c := client.NewREST(apiKey, &interfaces.ClientOptions{
 		APIKey: apiKey,
 	})
+	// the SDK returns no client when it can't find an API key
+	if c != nil {
+		c.HTTPClient.Transport = newTransport(c.HTTPClient.Transport)
+	}
 	dg := api.New(c)
 
 	return dg, nil
 }
 
+// newTransport returns the HTTP transport used to talk to Deepgram. The SDK
+// default keeps at most 2 idle connections per host, so with more workers than
+// that most requests would open a new connection. The TLS and proxy settings
+// of sdk, the transport the SDK made from its client options, are kept.
+func newTransport(sdk http.RoundTripper) *http.Transport {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if sdk, ok := sdk.(*http.Transport); ok {
+		if sdk.TLSClientConfig != nil {
+			tr.TLSClientConfig = sdk.TLSClientConfig.Clone()
+		}
+		if sdk.Proxy != nil {
+			tr.Proxy = sdk.Proxy
+		}
+	}
+	tr.MaxIdleConns = cfg.GetInt("max-idle-conns")
+	tr.MaxIdleConnsPerHost = cfg.GetInt("max-idle-conns-per-host")
+	tr.IdleConnTimeout = cfg.GetDuration("idle-conn-timeout")
+	tr.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: cfg.GetDuration("keep-alive"),
+	}).DialContext
+	return tr
+}
+
 var AudioExtensions = []string{".wav", ".mp3", ".m4a", ".flac", ".ogg", ".opus", ".webm", ".aac", ".wma", ".aiff", ".aif", ".aifc", ".caf", ".amr", ".au", ".snd", ".gsm", ".m4r", ".3gp", ".3g2", ".aa", ".aax", ".act", ".aup", ".awb", ".dct", ".dss", ".dvf", ".flac", ".gsm", ".ivs", ".m4a", ".m4b", ".m4p", ".mmf", ".mpc", ".msv", ".nmf", ".nsf", ".ogg", ".oga", ".mogg", ".opus", ".ra", ".rm", ".raw", ".sln", ".tta", ".vox", ".wav", ".wma", ".wv", ".webm", ".8svx", ".cda"}
 var VideoExtensions = []string{".mp4", ".mov", ".avi", ".mkv", ".flv", ".wmv", ".webm", ".m4v", ".3gp", ".3g2", ".asf"}
 
 type FilePath string
 
+// Dir returns the directory of f, the current one for URLs.
 func (f FilePath) Dir() string {
+	if isURL(string(f)) {
+		return "."
+	}
 	return filepath.Dir(string(f))
 }
 
 func (f FilePath) Name() string {
+	if isURL(string(f)) {
+		return urlName(string(f))
+	}
 	return filepath.Base(string(f))
 }
 
 func (f FilePath) Ext() string {
-	return filepath.Ext(string(f))
+	return filepath.Ext(f.Name())
+}
+
+// OutputDir returns the directory the outputs of f are written to. That's the
+// directory of f, unless --output-dir is set, in which case the structure of
+// the inputs is mirrored under it: outputs of dir/file.mp4 go in
+// <output-dir>/dir, and outputs of files outside the current directory under
+// their absolute path in <output-dir>.
+func (f FilePath) OutputDir() string {
+	root := cfg.GetString("output-dir")
+	if root == "" {
+		return f.Dir()
+	}
+
+	dir, err := filepath.Abs(f.Dir())
+	if err != nil {
+		return filepath.Join(root, f.Dir())
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		rel, err := filepath.Rel(cwd, dir)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.Join(root, rel)
+		}
+	}
+	return filepath.Join(root, strings.TrimPrefix(dir, filepath.VolumeName(dir)))
 }
 
+// Base returns the name of f without its extension, which names its outputs.
+// For URLs it's followed by the start of the hash of the URL, like
+// talk_1a2b3c4d, so different URLs of the same name don't share outputs.
 func (f FilePath) Base() string {
-	return strings.TrimSuffix(f.Name(), f.Ext())
+	base := strings.TrimSuffix(f.Name(), f.Ext())
+	if isURL(string(f)) {
+		return base + "_" + urlHash(string(f))[:urlHashLength]
+	}
+	return base
 }
 
 func (f FilePath) Exists() bool {
 	return fsys.FileExists(string(f))
 }
 
-func audioForFile(file FilePath) (FilePath, error) {
-	isVideo := slices.Contains(VideoExtensions, file.Ext())
-	if isVideo {
-		dir := filepath.Join(file.Dir(), audioDirectory)
-		for _, ext := range AudioExtensions {
-			audioFile := FilePath(filepath.Join(dir, file.Base()+ext))
-			if audioFile.Exists() {
-				return audioFile, nil
-			}
-		}
+// isMedia reports whether file has the extension of an audio or video file,
+// in any case.
+func isMedia(file string) bool {
+	ext := strings.ToLower(filepath.Ext(file))
+	return slices.Contains(AudioExtensions, ext) || slices.Contains(VideoExtensions, ext)
+}
 
-		err := os.MkdirAll(dir, os.ModePerm)
+// InputFiles returns the files given as arguments, which can be globs,
+// directories or http(s) URLs. Directories are searched recursively for audio
+// and video files. A single - argument reads the paths from stdin instead, one
+// per line.
+func InputFiles(args []string) ([]string, error) {
+	if len(args) == 1 && args[0] == "-" {
+		files, err := filesFromStdin()
 		if err != nil {
-			return "", fmt.Errorf("creating audio directory %q: %w", dir, err)
+			return nil, err
 		}
+		return fsys.ExpandDirs(files, isMedia, outputDirNames()...)
+	}
 
-		audioPath := FilePath(filepath.Join(dir, file.Base()+".mp3"))
+	var files []string
+	for _, arg := range args {
+		if isURL(arg) {
+			files = append(files, arg)
+			continue
+		}
+		matches, err := fsys.FilesFromGlobs([]string{arg})
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	return fsys.ExpandDirs(files, isMedia, outputDirNames()...)
+}
 
-		fmt.Printf("Converting %q to %q\n", file, audioPath)
-		err = ffmpeg.
-			Input(string(file)).
-			Output(string(audioPath)).
-			OverWriteOutput().
-			Silent(true).
-			Run()
+// sinceCutoff returns the time files must have been modified after to be
+// processed, from --since, or the zero time when every file is.
+func sinceCutoff() (time.Time, error) {
+	since := cfg.GetString("since")
+	if since == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(since); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--since takes a duration, like 24h, or an RFC3339 time, like 2024-05-01T00:00:00Z, got %q", since)
+	}
+	return t, nil
+}
 
+// modifiedSince returns the files last modified after cutoff.
+func modifiedSince(files []string, cutoff time.Time) ([]string, error) {
+	var recent []string
+	for _, file := range files {
+		if isURL(file) {
+			// there's no telling when a remote file changed
+			recent = append(recent, file)
+			continue
+		}
+		info, err := os.Stat(file)
 		if err != nil {
-			return "", fmt.Errorf("running ffmpeg converting %q to %q: %w", file, audioPath, err)
+			return nil, fmt.Errorf("getting modification time of %q: %w", file, err)
+		}
+		if info.ModTime().After(cutoff) {
+			recent = append(recent, file)
 		}
+	}
+	return recent, nil
+}
 
-		return audioPath, nil
+// filesFromStdin reads the non-empty lines of stdin as paths.
+func filesFromStdin() ([]string, error) {
+	var files []string
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			files = append(files, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading files from stdin: %w", err)
+	}
+	return files, nil
+}
+
+// mediaExt returns the extension used to decide whether file is audio or video,
+// in lower case, so VIDEO.MOV is a video too. When --input-format is set, it
+// takes precedence over the file name, which allows handling files with a
+// missing or misleading extension.
+func mediaExt(file FilePath) string {
+	if format := cfg.GetString("input-format"); format != "" {
+		return "." + strings.ToLower(strings.TrimPrefix(format, "."))
+	}
+	return strings.ToLower(file.Ext())
+}
+
+func audioForFile(file FilePath) (FilePath, error) {
+	// Deepgram fetches remote files itself
+	if isURL(string(file)) {
+		return file, nil
+	}
+
+	isVideo := slices.Contains(VideoExtensions, mediaExt(file))
+	if isVideo {
+		return extractAudio(file)
 	}
 
-	isAudio := slices.Contains(AudioExtensions, file.Ext())
+	isAudio := slices.Contains(AudioExtensions, mediaExt(file))
 	if isAudio {
 		return file, nil
 	}
 
+	// let ffmpeg decide if it can demux files with extensions we don't know
+	if cfg.GetBool("force-ffmpeg") {
+		return extractAudio(file)
+	}
+
 	return "", fmt.Errorf("file %q is not a supported audio or video file", file)
 }
 
-func ProcessFile(dg *api.Client, file FilePath) (*interfacesv1.PreRecordedResponse, error) {
+// audioKwArgs returns the ffmpeg output options of extracted audio, from
+// --audio-sample-rate and --audio-bitrate.
+func audioKwArgs() ffmpeg.KwArgs {
+	args := ffmpeg.KwArgs{}
+	if rate := cfg.GetInt("audio-sample-rate"); rate > 0 {
+		args["ar"] = rate
+	}
+	if bitrate := cfg.GetString("audio-bitrate"); bitrate != "" {
+		args["ab"] = bitrate
+	}
+	return args
+}
+
+// audioFormatExt returns the extension of the files audio is extracted to.
+func audioFormatExt() string {
+	return "." + strings.TrimPrefix(cfg.GetString("audio-format"), ".")
+}
 
-	transcriptDir := filepath.Join(file.Dir(), transcriptionDirectory)
-	transcript := FilePath(filepath.Join(transcriptDir, file.Base()+"_response.json"))
-	if transcript.Exists() {
-		fmt.Printf("Transcript file %q already exists, using it\n", transcript)
-		var r interfacesv1.PreRecordedResponse
-		fileData, err := os.ReadFile(string(transcript))
-		if err != nil {
-			return nil, fmt.Errorf("reading existing transcript file %q: %w", transcript, err)
+// convertedAudio returns the audio extracted from file by an earlier run, or
+// "" if there's none. When there are several, from runs with different
+// settings, the one in --audio-format wins, otherwise the newest one.
+func convertedAudio(file FilePath) FilePath {
+	dir := audioDir(file)
+	name := file.audioName()
+	preferred := FilePath(filepath.Join(dir, name+audioFormatExt()))
+	if preferred.Exists() {
+		return preferred
+	}
+
+	var newest FilePath
+	var newestTime time.Time
+	for _, ext := range AudioExtensions {
+		audioFile := FilePath(filepath.Join(dir, name+ext))
+		info, err := os.Stat(string(audioFile))
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if newest == "" || info.ModTime().After(newestTime) {
+			newest, newestTime = audioFile, info.ModTime()
 		}
-		err = json.Unmarshal(fileData, &r)
+	}
+	return newest
+}
+
+// reusableAudio returns the audio extracted from file by an earlier run that
+// this run can use, or "" if it has to be extracted again, with --force.
+// Audio of a file modified after it was extracted is never reused, since it's
+// named by the modification time of the file, see audioName.
+func reusableAudio(file FilePath) FilePath {
+	audioFile := convertedAudio(file)
+	if audioFile == "" || cfg.GetBool("force") {
+		return ""
+	}
+	return audioFile
+}
+
+// extractAudio uses ffmpeg to extract the audio of file into the audio
+// directory, reusing a previously extracted audio file if there is one, see
+// reusableAudio.
+func extractAudio(file FilePath) (FilePath, error) {
+	dir := audioDir(file)
+	if audioFile := reusableAudio(file); audioFile != "" {
+		infof("Using converted audio %q for %q\n", audioFile, file)
+		return audioFile, nil
+	}
+
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return "", fmt.Errorf("creating audio directory %q: %w", dir, err)
+	}
+
+	audioPath := FilePath(filepath.Join(dir, file.audioName()+audioFormatExt()))
+
+	infof("Converting %q to %q\n", file, audioPath)
+	err = ffmpeg.
+		Input(string(file)).
+		Output(string(audioPath), audioKwArgs()).
+		OverWriteOutput().
+		Silent(true).
+		Run()
+
+	if err != nil {
+		return "", fmt.Errorf("running ffmpeg converting %q to %q: %w", file, audioPath, err)
+	}
+
+	return audioPath, nil
+}
+
+// transcriptPath returns the path where the Deepgram response for file is cached.
+func transcriptPath(file FilePath) FilePath {
+	// not named by --name-template, so every command finds the cache, and it
+	// isn't invalidated by {date}
+	return FilePath(filepath.Join(transcriptionDir(file), file.Base()+"_response.json"))
+}
+
+// LoadTranscript reads the cached Deepgram response for file. If there is no
+// cached response, the returned error wraps fs.ErrNotExist.
+func LoadTranscript(file FilePath) (*interfacesv1.PreRecordedResponse, error) {
+	return readTranscript(transcriptPath(file), 1)
+}
+
+// transcriptionOptions returns the options sent to Deepgram with each file.
+func transcriptionOptions() *interfaces.PreRecordedTranscriptionOptions {
+	opts := &interfaces.PreRecordedTranscriptionOptions{
+		// unknown models are left for Deepgram to reject
+		Model:       cfg.GetString("model"),
+		Punctuate:   cfg.GetBool("punctuate"),
+		Paragraphs:  cfg.GetBool("paragraphs"),
+		SmartFormat: cfg.GetBool("smart-format"),
+		Diarize:     cfg.GetBool("diarize"),
+		Utterances:  cfg.GetBool("utterances"),
+		// speakers are labeled per channel when rendering, see channelLabel
+		Multichannel: cfg.GetBool("multichannel"),
+		// topics make the table of contents of the md format
+		Topics:   cfg.GetBool("topics"),
+		Keywords: cfg.GetStringSlice("keyword"),
+	}
+
+	if language := cfg.GetString("language"); language == autoLanguage {
+		opts.DetectLanguage = true
+	} else {
+		opts.Language = language
+	}
+
+	// --opt comes last, so it can override any of the options above
+	applyOptions(opts)
+	return opts
+}
+
+func ProcessFile(ctx context.Context, dg *api.Client, file FilePath) (*interfacesv1.PreRecordedResponse, error) {
+
+	isVideo := slices.Contains(VideoExtensions, mediaExt(file))
+	isAudio := slices.Contains(AudioExtensions, mediaExt(file))
+	// Deepgram decides whether it can transcribe remote files
+	supported := isVideo || isAudio || cfg.GetBool("force-ffmpeg") || isURL(string(file))
+
+	// with --no-cache transcripts are neither looked up nor stored, with
+	// --force they are stored without being looked up
+	useCache := !cfg.GetBool("no-cache")
+	reuse := useCache && !cfg.GetBool("force")
+	if reuse && runManifest.optionsChanged(file) && !cfg.GetBool("overwrite-srt-only") {
+		progressf(ctx, "The options of the transcript of %q changed, transcribing it again\n", file)
+		reuse = false
+	}
+
+	var shared FilePath
+	if supported && useCache {
+		var err error
+		shared, err = sharedTranscriptPath(file)
 		if err != nil {
-			return nil, fmt.Errorf("unmarshaling existing transcript file %q: %w", transcript, err)
+			return nil, err
 		}
-		return &r, nil
 	}
 
-	isVideo := slices.Contains(VideoExtensions, file.Ext())
-	isAudio := slices.Contains(AudioExtensions, file.Ext())
+	if reuse && shared != "" && shared.Exists() {
+		progressf(ctx, "Using shared transcript %q for %q\n", shared, file)
+		logEvent(ctx, "transcript_cached", file, "path", string(shared))
+		return readTranscript(shared, sharedReadAttempts)
+	}
+
+	transcript := transcriptPath(file)
+	if reuse && transcript.Exists() {
+		progressf(ctx, "Transcript file %q already exists, using it\n", transcript)
+		logEvent(ctx, "transcript_cached", file, "path", string(transcript))
+		r, err := LoadTranscript(file)
+		if err == nil && shared != "" {
+			err = saveTranscript(shared, r)
+		}
+		return r, err
+	}
 
-	if !isVideo && !isAudio {
-		fmt.Printf("File %q is not a supported audio or video file, skipping\n", file)
+	if !supported {
+		progressf(ctx, "File %q is not a supported audio or video file, skipping\n", file)
 		return nil, nil
 	}
 
+	end := startStage(ctx, "convert")
 	audioFile, err := audioForFile(file)
+	end()
 	if err != nil {
 		return nil, fmt.Errorf("getting audio file for %q: %w", file, err)
 	}
+	// audio files dgram made, never the inputs themselves
+	var extracted []FilePath
+	if audioFile != file {
+		extracted = append(extracted, audioFile)
+		logEvent(ctx, "audio_extracted", file, "path", string(audioFile))
+	}
 
-	// Go context
-	ctx := context.Background()
-
-	// set the Transcription options
-	options := &interfaces.PreRecordedTranscriptionOptions{
-		Model:       "nova-2",
-		Punctuate:   true,
-		Paragraphs:  true,
-		SmartFormat: true,
-		Language:    "en-US",
-		Diarize:     true,
-		Utterances:  true,
+	err = checkUploadSize(audioFile)
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("Transcribing %q\n", file)
-	res, err := dg.FromFile(ctx, string(audioFile), options)
+	progressf(ctx, "Transcribing %q\n", file)
+	res, err := fromFile(ctx, dg, audioFile)
+	if err != nil && isUnsupportedMedia(err) {
+		if !cfg.GetBool("convert-on-reject") {
+			return nil, fmt.Errorf("deepgram can't decode the audio of %q, use --convert-on-reject to convert it with ffmpeg and retry: %w", audioFile, err)
+		}
+
+		progressf(ctx, "Deepgram can't decode the audio of %q, converting it and retrying\n", audioFile)
+		end = startStage(ctx, "convert")
+		audioFile, err = convertAudio(file)
+		end()
+		if err != nil {
+			return nil, err
+		}
+		extracted = append(extracted, audioFile)
+		logEvent(ctx, "audio_extracted", file, "path", string(audioFile))
+		err = checkUploadSize(audioFile)
+		if err != nil {
+			return nil, err
+		}
+		res, err = fromFile(ctx, dg, audioFile)
+	}
 	if err != nil {
-		if e, ok := err.(*interfaces.StatusError); ok {
-			return nil, fmt.Errorf("deepgram status error (%s) %s ", e.DeepgramError.ErrCode, e.DeepgramError.ErrMsg)
+		return nil, deepgramError(err)
+	}
+	logEvent(ctx, "transcript_fetched", file, "request_id", res.RequestID)
+
+	if cfg.GetString("language") == autoLanguage {
+		progressf(ctx, "Detected language %q for %q\n", detectedLanguage(res), file)
+	}
+
+	// captions piped from stdout shouldn't leave files behind, --print-json
+	// still caches the response it prints
+	if useCache && !cfg.GetBool("stdout") {
+		err = saveTranscript(transcript, res)
+		if err != nil {
+			return nil, err
+		}
+		progressf(ctx, "Transcript saved to %q\n", transcript)
+
+		err = runManifest.record(file, transcript)
+		if err != nil {
+			warnf(ctx, "Can't add %q to the manifest: %v\n", file, err)
+		}
+
+		if shared != "" {
+			err = saveTranscript(shared, res)
+			if err != nil {
+				return nil, err
+			}
 		}
-		return nil, fmt.Errorf("getting response from deepgram: %w", err)
 	}
 
-	data, err := json.MarshalIndent(res, "", "  ")
-	if err != nil {
-		return nil, fmt.Errorf("marshaling file response: %w", err)
+	if !cfg.GetBool("keep-audio") {
+		for _, audio := range extracted {
+			err = os.Remove(string(audio))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				warnf(ctx, "Can't remove extracted audio %q: %v\n", audio, err)
+			}
+		}
 	}
 
-	err = os.MkdirAll(transcriptDir, os.ModePerm)
+	return res, nil
+}
+
+// toStdout reports whether the output of the run goes to stdout, with
+// --stdout or --print-json, instead of to files. Status messages go to stderr
+// then.
+func toStdout() bool {
+	return cfg.GetBool("stdout") || cfg.GetBool("print-json")
+}
+
+// printJSON writes the Deepgram response r to stdout.
+func printJSON(r *interfacesv1.PreRecordedResponse) error {
+	data, err := json.MarshalIndent(r, "", "  ")
 	if err != nil {
-		return nil, fmt.Errorf("creating transcript directory %q: %w", transcriptDir, err)
+		return fmt.Errorf("marshaling file response: %w", err)
 	}
 
-	err = os.WriteFile(string(transcript), data, 0644)
+	_, err = fmt.Fprintln(os.Stdout, string(data))
 	if err != nil {
-		return nil, fmt.Errorf("writing transcript file %q: %w", transcript, err)
+		return fmt.Errorf("writing response to stdout: %w", err)
 	}
+	return nil
+}
 
-	fmt.Printf("Transcript saved to %q\n", transcript)
+// writeStdout writes the captions of r to stdout, in the single format
+// selected with --format.
+func writeStdout(r *interfacesv1.PreRecordedResponse) error {
+	captions, counts, err := renderCaptions(r, formats()[0])
+	if err != nil {
+		return err
+	}
+	logReplacements("stdout", counts)
 
-	return res, nil
+	_, err = fmt.Fprint(os.Stdout, captions)
+	if err != nil {
+		return fmt.Errorf("writing captions to stdout: %w", err)
+	}
+	return nil
 }
 
 var transcribeCmd = &cobra.Command{
 	Use:   "transcribe",
 	Short: "transcribe video and audio files",
 	Args:  cobra.MinimumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		err := cfg.ReadProjectConfig()
+		if err != nil {
+			return err
+		}
+		return cfg.BindPFlags(cmd.LocalFlags())
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		applyQuiet()
 
-		files, err := filesFromGlobs(args)
+		err := ValidateRenderFlags()
+		if err != nil {
+			return err
+		}
+		_, err = maxUploadSize()
+		if err != nil {
+			return err
+		}
+		err = checkOptions()
+		if err != nil {
+			return err
+		}
+		err = checkKeywords()
+		if err != nil {
+			return err
+		}
+		cutoff, err := sinceCutoff()
+		if err != nil {
+			return err
+		}
+		if n := cfg.GetInt("concurrency"); n < 1 {
+			return fmt.Errorf("--concurrency must be at least 1, got %d", n)
+		}
+		for _, key := range dirNameFlags {
+			err := checkDirName(key)
+			if err != nil {
+				return err
+			}
+		}
+		if !slices.Contains(graphFormats, cfg.GetString("graph-format")) {
+			return fmt.Errorf("unsupported --graph-format %q, supported formats are: %s", cfg.GetString("graph-format"), strings.Join(graphFormats, ", "))
+		}
+		if !slices.Contains(graphTypes, cfg.GetString("graph-type")) {
+			return fmt.Errorf("unsupported --graph-type %q, supported types are: %s", cfg.GetString("graph-type"), strings.Join(graphTypes, ", "))
+		}
+		if cfg.GetString("graph-type") != "bar" && cfg.GetString("graph-format") != "html" {
+			return fmt.Errorf("--graph-type %s needs --graph-format html, %s graphs are bar charts", cfg.GetString("graph-type"), cfg.GetString("graph-format"))
+		}
+		minDuration, maxDuration := cfg.GetDuration("min-duration"), cfg.GetDuration("max-duration")
+		if minDuration < 0 || maxDuration < 0 {
+			return fmt.Errorf("--min-duration and --max-duration can't be negative")
+		}
+		if maxDuration > 0 && minDuration > maxDuration {
+			return fmt.Errorf("--min-duration %s is longer than --max-duration %s", minDuration, maxDuration)
+		}
+		if graphBucket() <= 0 {
+			return fmt.Errorf("--graph-bucket must be positive, got %s", graphBucket())
+		}
+		cmd.SilenceUsage = true
+
+		// these never call Deepgram
+		offline := cfg.GetBool("dry-run") || cfg.GetBool("estimate") || cfg.GetBool("overwrite-srt-only")
+		if cfg.APIKey() == "" && !offline {
+			return fmt.Errorf("no Deepgram API key configured, set one with: dgram config set apikey <key>, $DEEPGRAM_API_KEY or --api-key")
+		}
+
+		var files []string
+		if !cfg.GetBool("watch") {
+			files, err = InputFiles(args)
+		}
 		if err != nil {
 			return fmt.Errorf("getting file paths: %w", err)
 		}
+		if !cutoff.IsZero() {
+			recent, err := modifiedSince(files, cutoff)
+			if err != nil {
+				return err
+			}
+			if skipped := len(files) - len(recent); skipped > 0 {
+				infof("Skipping %d of %d files not modified since %s\n", skipped, len(files), cutoff.Format(time.RFC3339))
+			}
+			files = recent
+		}
+
+		if kept := withinDuration(files); len(kept) < len(files) {
+			infof("Skipping %d of %d files outside of the --min-duration and --max-duration range\n", len(files)-len(kept), len(files))
+			files = kept
+		}
+
+		if !cfg.GetBool("no-cache") {
+			runManifest, err = loadManifest()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				err := runManifest.flush()
+				if err != nil {
+					logf("Can't write the manifest: %v\n", err)
+				}
+			}()
+		}
 
-		dg, err := getDgClient(cfg.GetString("apikey"))
+		if cfg.GetBool("dry-run") {
+			return printPlan(files)
+		}
+		if cfg.GetBool("estimate") {
+			return printEstimate(files)
+		}
+		err = checkFFmpeg(files)
 		if err != nil {
-			return fmt.Errorf("creating deepgram client: %w", err)
+			return err
 		}
 
-		type FileResult struct {
-			File string  `json:"file"`
-			WPM  float64 `json:"wpm"`
+		if cfg.GetBool("stdout") && (len(files) != 1 || len(formats()) != 1) {
+			return fmt.Errorf("--stdout needs exactly one input file and one --format, got %d files and %d formats", len(files), len(formats()))
+		}
+		if cfg.GetBool("print-json") && len(files) != 1 {
+			return fmt.Errorf("--print-json needs exactly one input file, got %d files", len(files))
+		}
+		if toStdout() {
+			status = os.Stderr
+		}
+		if cfg.GetBool("log-json") {
+			// keep stdout for the events, so it can be parsed line by line
+			eventLog = newEventLog(os.Stdout)
+			status = os.Stderr
 		}
 
-		type JobResult struct {
-			FileResult FileResult
-			Error      error
+		var dg *api.Client
+		if !offline {
+			dg, err = getDgClient(cfg.APIKey())
+			if err != nil {
+				return fmt.Errorf("creating deepgram client: %w", err)
+			}
 		}
 
-		const maxWorkers = 4
-		jobs := make(chan string, len(files))
-		results := make(chan JobResult, len(files))
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
 
-		var wg sync.WaitGroup
+		// the first interrupt stops starting new files and lets the ones being
+		// processed finish, the second one cancels them too
+		interrupted := make(chan struct{})
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+		go func() {
+			select {
+			case <-signals:
+			case <-ctx.Done():
+				return
+			}
+			logf("Interrupted, finishing the files being processed (interrupt again to cancel them)\n")
+			close(interrupted)
+
+			select {
+			case <-signals:
+				logf("Interrupted again, cancelling the files being processed\n")
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
 
-		// Start worker goroutines
-		for i := 0; i < maxWorkers; i++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				for file := range jobs {
-					fp := FilePath(file)
+		if cfg.GetBool("watch") {
+			return watch(ctx, interrupted, dg, args, cfg.GetInt("concurrency"))
+		}
 
-					// Skip files that are currently being downloaded
-					if fsys.IsBeingDownloaded(string(fp)) {
-						fmt.Printf("Skipping %q - file is currently being downloaded\n", file)
-						continue
-					}
+		maxErrors := cfg.GetInt("max-errors")
+		if cfg.GetBool("fail-fast") {
+			maxErrors = 1
+		}
+		totals := newBatchTotals()
+		failed := make([]jobResult, 0)
 
-					r, err := ProcessFile(dg, fp)
-					if err != nil {
-						results <- JobResult{Error: fmt.Errorf("processing file %q: %w", file, err)}
-						continue
-					}
+		err = createSummaryDirs()
+		if err != nil {
+			return err
+		}
+
+		var log *resultLog
+		if !toStdout() {
+			log, err = createResultLog()
+			if err != nil {
+				return err
+			}
+			defer log.Close()
+		}
 
-					err = CreateGraph(r, fp)
+		// the summary gets each file as it's done, instead of keeping every
+		// result until the batch ends
+		var summary *summaryWriter
+
+		diskFull := false
+
+		// collect gathers the results of a pass over the files
+		collect := func(results <-chan jobResult) {
+			for result := range results {
+				if result.Skipped {
+					continue
+				}
+				if log != nil {
+					err := log.write(result)
 					if err != nil {
-						results <- JobResult{Error: fmt.Errorf("creating graph: %w", err)}
+						logf("%v\n", err)
+					}
+				}
+				if result.Error != nil {
+					if ctx.Err() != nil && errors.Is(result.Error, context.Canceled) {
+						// cancelled because of earlier errors, not a failure of its own
 						continue
 					}
-
-					srtPath := filepath.Join(fp.Dir(), fp.Base()+".srt")
-
-					if !fsys.FileExists(srtPath) {
-						conv := converters.NewDeepgramConverter(r)
-						srt, err := renderers.SRT(conv)
-						if err != nil {
-							results <- JobResult{Error: fmt.Errorf("rendering SRT for %s: %w", file, err)}
-							continue
-						}
-
-						err = os.WriteFile(srtPath, []byte(srt), 0644)
-						if err != nil {
-							results <- JobResult{Error: fmt.Errorf("writing SRT file %q: %w", srtPath, err)}
-							continue
-						}
-					} else {
-						fmt.Printf("SRT file %q already exists, skipping\n", srtPath)
+					failed = append(failed, result)
+					if errors.Is(result.Error, syscall.ENOSPC) && !diskFull {
+						// every write after this one would fail the same way
+						diskFull = true
+						logf("Disk full, stopping the batch\n")
+						cancel()
 					}
-
-					nWords := 0
-					for _, c := range r.Results.Channels {
-						nWords += len(c.Alternatives[0].Words)
+					if maxErrors > 0 && len(failed) == maxErrors {
+						logf("Reached %d errors, stopping the batch\n", maxErrors)
+						cancel()
 					}
-
-					wpm := float64(nWords) / (r.Metadata.Duration / 60)
-					results <- JobResult{FileResult: FileResult{File: file, WPM: wpm}}
+					continue
 				}
-			}()
+				if summary != nil {
+					err := summary.write(result.FileResult)
+					if err != nil {
+						logf("%v\n", err)
+					}
+				}
+				totals.add(result.FileResult)
+			}
 		}
 
-		// Send jobs to workers
-		go func() {
-			defer close(jobs)
-			for _, file := range files {
-				jobs <- file
+		queue := files
+		var duplicates map[string][]string
+		if cfg.GetBool("dedup") {
+			queue, duplicates, err = dedupFiles(files)
+			if err != nil {
+				return err
 			}
-		}()
+			if n := len(files) - len(queue); n > 0 {
+				infof("Skipping %d duplicate files, they reuse the transcripts of the files with the same contents\n", n)
+			}
+		}
 
-		// Wait for all workers to finish
-		go func() {
-			wg.Wait()
-			close(results)
-		}()
+		if !toStdout() && !cfg.GetBool("transcript-only") {
+			summary, err = createSummary()
+			if err != nil {
+				return err
+			}
+		}
+
+		started := time.Now()
+		workers := cfg.GetInt("concurrency")
+		collect(runJobs(ctx, interrupted, dg, queue, workers))
 
-		// Collect results
-		errors := make([]JobResult, 0)
-		wpms := make([]FileResult, 0, len(files))
-		for result := range results {
-			if result.Error != nil {
-				errors = append(errors, result)
-				continue
+		if cfg.GetBool("retry-failed") && len(failed) > 0 && ctx.Err() == nil && !closed(interrupted) {
+			retry := make([]string, 0, len(failed))
+			for _, f := range failed {
+				retry = append(retry, f.FileResult.File)
 			}
-			wpms = append(wpms, result.FileResult)
+			failed = failed[:0]
+
+			infof("Second pass: retrying %d failed files\n", len(retry))
+			collect(runJobs(ctx, interrupted, dg, retry, workers))
 		}
 
-		slices.SortFunc(wpms, func(a, b FileResult) int {
-			if a.WPM < b.WPM {
-				return 1
+		if len(duplicates) > 0 && ctx.Err() == nil && !closed(interrupted) {
+			// the transcripts of the duplicates are copies, so only their
+			// outputs are left to write
+			var shared []string
+			for _, file := range totals.files {
+				for _, dup := range duplicates[file] {
+					err := shareTranscript(file, dup)
+					if err != nil {
+						failed = append(failed, jobResult{FileResult: FileResult{File: dup}, Error: err})
+						continue
+					}
+					shared = append(shared, dup)
+				}
 			}
-			if a.WPM > b.WPM {
-				return -1
+			for _, f := range failed {
+				for _, dup := range duplicates[f.FileResult.File] {
+					failed = append(failed, jobResult{FileResult: FileResult{File: dup}, Error: fmt.Errorf("not transcribed, it's a duplicate of %q, which failed", f.FileResult.File)})
+				}
 			}
+			collect(runJobs(ctx, interrupted, dg, shared, workers))
+		}
+		logThroughput(totals, len(failed), time.Since(started))
 
-			return 0
-		})
+		if summary != nil {
+			err = summary.Close()
+			if err != nil {
+				return err
+			}
+		}
 
-		wpms_json, err := json.MarshalIndent(wpms, "", "  ")
-		if err != nil {
-			return fmt.Errorf("marshaling wpms: %w", err)
+		if cfg.GetBool("index") && !toStdout() && !cfg.GetBool("transcript-only") && !cfg.GetBool("summary-only") {
+			sortByWPM(totals.indexed)
+			err = writeIndex(totals.indexed)
+			if err != nil {
+				return err
+			}
 		}
 
-		err = os.WriteFile("wpms.json", wpms_json, 0644)
-		if err != nil {
-			return fmt.Errorf("writing wpms.json: %w", err)
+		if cfg.GetBool("language-detect-report") {
+			err = writeLanguageReport(totals.languages)
+			if err != nil {
+				return err
+			}
+		}
+
+		if cfg.GetBool("turn-taking") {
+			err = writeTurnTaking(totals.turnTaking)
+			if err != nil {
+				return err
+			}
 		}
 
-		if len(errors) > 0 {
-			fmt.Println("Some errors occurred during processing these files:")
-			for _, e := range errors {
-				fmt.Printf("  - %v (%v)\n", e.FileResult.File, e.Error)
+		logWarnings(totals.warned)
+
+		processed := slices.Clone(totals.files)
+		slices.Sort(processed)
+		problems := verifyArtifacts(processed)
+		if len(problems) > 0 {
+			logf("Some files are missing expected outputs:\n")
+			for _, file := range processed {
+				if p, ok := problems[file]; ok {
+					logf("  - %v (%v)\n", file, strings.Join(p, "; "))
+				}
 			}
 		}
+
+		if len(failed) > 0 {
+			logf("Some errors occurred during processing these files:\n")
+			for _, e := range failed {
+				logf("  - %v (%v)\n", e.FileResult.File, e.Error)
+			}
+		}
+
+		if cfg.GetBool("strict") && len(problems) > 0 {
+			return fmt.Errorf("%d files are missing expected outputs", len(problems))
+		}
+		if diskFull {
+			return fmt.Errorf("disk full: stopped after %d of %d files were processed, free some space and run again to process the rest", len(totals.files)+len(failed), len(files))
+		}
+		if closed(interrupted) {
+			return fmt.Errorf("interrupted: %d of %d files were processed, %d of them failed, run again to process the rest", len(totals.files)+len(failed), len(files), len(failed))
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("stopped after %d errors, %d of %d files were processed", len(failed), len(totals.files)+len(failed), len(files))
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("%d of %d files failed", len(failed), len(files))
+		}
 		return nil
 	},
 }
 
-func generateWordCountSeries(r *interfacesv1.PreRecordedResponse) []opts.BarData {
-	mins := int(math.Trunc(r.Metadata.Duration/60) + 1)
-	counts := make([]int, mins)
+func init() {
+	transcribeCmd.PersistentFlags().String("language", "en-US", "language of the audio, like pt-BR or es, or auto to let Deepgram detect it")
+	transcribeCmd.PersistentFlags().String("model", "nova-2", "Deepgram model to transcribe with, like nova-3, whisper-large or base")
+
+	flags := transcribeCmd.Flags()
+	flags.String("api-key", "", "Deepgram API key, over $DEEPGRAM_API_KEY and the apikey config key")
+	flags.Bool("dry-run", false, "list what would be done to each file, without running ffmpeg or calling Deepgram")
+	flags.Bool("estimate", false, "print how many minutes of audio would be sent to Deepgram, probing each input with ffprobe, without calling Deepgram")
+	flags.Float64("rate-per-minute", 0, "price of a minute of audio, to also print the estimated cost of the run with --estimate")
+	flags.Duration("min-duration", 0, "only process the files that last at least this long, like 5m, found with ffprobe")
+	flags.Duration("max-duration", 0, "only process the files that last at most this long, like 1h, found with ffprobe (0 means no limit)")
+	flags.String("since", "", "only process the files modified in this last duration, like 24h, or after this RFC3339 time, like 2024-05-01T00:00:00Z")
+	flags.String("input-format", "", "treat every input as this container format (e.g. mp4), regardless of its extension")
+	flags.Bool("punctuate", true, "ask Deepgram to punctuate and capitalize the transcript, use --punctuate=false for raw words")
+	flags.Bool("smart-format", true, "ask Deepgram to format numbers, dates, emails and the like, use --smart-format=false to keep them as spoken")
+	flags.Bool("paragraphs", true, "ask Deepgram to split the transcript into paragraphs, which the txt, tsv and md formats are made of")
+	flags.Bool("utterances", true, "ask Deepgram to split the transcript into utterances, which captions and speaker turns are made of")
+	flags.Bool("multichannel", false, "transcribe each audio channel separately, speakers are then labeled by channel, like C0-S1")
+	flags.StringArray("keyword", nil, "term Deepgram should be more likely to recognize, with how much to boost it, like kubernetes:2 (negative boosts suppress it), can be repeated")
+	flags.StringArray("opt", nil, "Deepgram option to set, as named in its API, like numerals=true or keywords=dgram:2, can be repeated")
+	flags.Bool("topics", false, "ask Deepgram for the topics of each file, listed as a table of contents by the md format")
+	flags.Bool("check-levels", false, "check the audio of each input with ffmpeg and warn about clipping or very low levels")
+	flags.Bool("convert-on-reject", false, "when Deepgram can't decode a file, convert it to WAV with ffmpeg and send it again")
+	flags.Int("audio-sample-rate", 16000, "sample rate of the audio extracted from videos, in Hz (0 keeps the one of the video)")
+	flags.String("audio-bitrate", "", "bitrate of the audio extracted from videos, like 32k or 64k (default is ffmpeg's)")
+	flags.Bool("keep-audio", true, "keep the audio extracted from videos, use --keep-audio=false to delete it once the video is transcribed")
+	flags.Bool("replace-existing-audio", false, "extract the audio of videos again when they were modified after their audio was extracted")
+	// audio is named by the modification time of its source, so it is always
+	// extracted again from modified videos
+	flags.MarkDeprecated("replace-existing-audio", "the audio of modified videos is always extracted again")
+	flags.String("audio-format", "mp3", "format of the audio extracted from videos, also preferred when a video has audio extracted in several formats")
+	flags.Bool("force-ffmpeg", false, "extract the audio with ffmpeg from files with unknown extensions instead of skipping them")
+	flags.String("cache-dir", "", "shared directory where transcripts are looked up and stored by content hash, before the per-file cache")
+	flags.String("audio-dir-name", audioDirectory, "name of the directory, next to each output, where extracted audio goes")
+	flags.String("transcriptions-dir-name", transcriptionDirectory, "name of the directory, next to each output, where transcripts are cached")
+	flags.String("graphs-dir-name", graphsDirectory, "name of the directory, next to each output, where graphs go")
+	flags.String("graph-format", "html", "format of the graph of each file (html, png, svg), png and svg are static images for reports")
+	flags.String("graph-type", "bar", "chart of the graph of each file (bar, line, area), line and area add up the words over time")
+	flags.Duration("graph-bucket", time.Minute, "width of the bins the words of each file are counted in by its graph, like 10s for short clips")
+	AddSummaryFlags(flags)
+	flags.Bool("index", false, "also write index.html linking the graph of each file, along with its words per minute")
+	flags.Bool("language-detect-report", false, "write languages.json with the files grouped by the language Deepgram detected")
+	flags.Bool("turn-taking", false, "write turntaking.json with the turns, interruptions and silences between turns of the speakers of each file")
+	flags.IntP("concurrency", "j", 4, "number of files processed at the same time")
+	flags.Int("retries", 3, "how many times a request Deepgram failed with a temporary error (429 or 5xx) is retried, waiting longer each time")
+	flags.Bool("retry-failed", false, "once the batch is done, retry the files that failed one more time")
+	flags.String("max-upload-size", "2GB", "largest audio file sent to Deepgram, files are uploaded whole and the ones over it fail before being uploaded, split them yourself (0 means no limit)")
+	flags.Bool("trace", false, "write a .trace.json file per input with how long each stage of processing it took")
+	flags.Bool("meta", false, "write a .meta.json file per input describing how its transcript was produced")
+	flags.Bool("strict", false, "fail the run when a processed file is missing any of its expected outputs, or one of them is empty")
+	flags.Bool("fail-fast", false, "stop the batch at the first file that fails, same as --max-errors 1")
+	flags.Int("max-errors", 0, "stop the batch once this many files have failed (0 means never stop)")
+	flags.Bool("stdout", false, "write the captions of a single file to stdout, in the one format given to --format (json for the Deepgram response), instead of writing files, without caching its transcript")
+	flags.Bool("print-json", false, "write the Deepgram response of a single file to stdout instead of rendering it, still caching it unless --no-cache is given")
+	flags.BoolP("quiet", "q", false, "only write warnings and errors, to stderr, leaving out the messages about what's being done to each file")
+	flags.Bool("log-json", false, "write an event per line to stdout as JSON for each step of processing a file, like file_started or file_done, with the other messages on stderr")
+	flags.Bool("force", false, "redo everything for each file, extracting its audio, transcribing it and replacing its captions even if they already exist")
+	flags.Bool("watch", false, "watch the directories given as arguments and transcribe the media files that appear in them, until interrupted")
+	flags.Bool("overwrite-srt-only", false, "render the captions of the files with a cached transcript again, replacing the existing ones, without calling Deepgram")
+	flags.Bool("dedup", false, "hash the inputs and only transcribe one of the files with the same contents, the others reuse its transcript")
+	flags.Bool("no-cache", false, "always ask Deepgram for a new transcript, without using or saving cached transcripts")
+	flags.Bool("transcript-only", false, "only fetch and cache the Deepgram response of each file, without rendering captions, graphs or the words per minute")
+	flags.Bool("summary-only", false, "only write the summary, skipping per-file captions and graphs")
+	flags.Duration("ramp-up", 0, "start the workers staggered over this window, like 10s, instead of all at once, to avoid a burst of requests")
+	flags.Int("max-idle-conns", 100, "maximum number of idle connections kept open to Deepgram")
+	flags.Int("max-idle-conns-per-host", 16, "maximum number of idle connections kept open per Deepgram host")
+	flags.Duration("idle-conn-timeout", 90*time.Second, "how long an idle connection to Deepgram is kept open")
+	flags.Duration("keep-alive", 30*time.Second, "interval between TCP keep-alive probes (negative disables them)")
+	AddRenderFlags(flags)
+
+	transcribeCmd.MarkFlagsMutuallyExclusive("stdout", "print-json", "transcript-only")
+	transcribeCmd.MarkFlagsMutuallyExclusive("log-json", "stdout", "print-json")
+	transcribeCmd.MarkFlagsMutuallyExclusive("dry-run", "estimate")
+	// the cache is all it renders from
+	transcribeCmd.MarkFlagsMutuallyExclusive("overwrite-srt-only", "force")
+	transcribeCmd.MarkFlagsMutuallyExclusive("overwrite-srt-only", "no-cache")
+	transcribeCmd.MarkFlagsMutuallyExclusive("overwrite-srt-only", "transcript-only")
+	// the duplicates reuse the cached transcripts
+	transcribeCmd.MarkFlagsMutuallyExclusive("dedup", "no-cache")
+	// a watch has no end, so none of the outputs of a whole run
+	for _, flag := range []string{"dry-run", "estimate", "stdout", "print-json", "dedup", "retry-failed"} {
+		transcribeCmd.MarkFlagsMutuallyExclusive("watch", flag)
+	}
+}
+
+// graphBuckets returns how many bins of --graph-bucket the graph of r has.
+func graphBuckets(r *interfacesv1.PreRecordedResponse) int {
+	return int(math.Trunc(r.Metadata.Duration/graphBucket().Seconds()) + 1)
+}
+
+// graphBucket returns the bin width of the graphs, from --graph-bucket.
+func graphBucket() time.Duration {
+	return cfg.GetDuration("graph-bucket")
+}
+
+// bucketName names the bin width d in the label of the graphs, like
+// "minute", "30 seconds" or "1m30s".
+func bucketName(d time.Duration) string {
+	unit := func(n time.Duration, name string) string {
+		if n == 1 {
+			return name
+		}
+		return fmt.Sprintf("%d %ss", n, name)
+	}
+	switch {
+	case d%time.Hour == 0:
+		return unit(d/time.Hour, "hour")
+	case d%time.Minute == 0:
+		return unit(d/time.Minute, "minute")
+	case d%time.Second == 0 && d < time.Minute:
+		return unit(d/time.Second, "second")
+	}
+	return d.String()
+}
+
+// wordCounts returns how many words of r start in each bin of its graph.
+func wordCounts(r *interfacesv1.PreRecordedResponse) []int {
+	buckets := graphBuckets(r)
+	counts := make([]int, buckets)
 
 	for _, c := range r.Results.Channels {
 		for _, w := range c.Alternatives[0].Words {
-			minute := int(w.Start / 60)
-			counts[minute]++
+			bucket := min(int(w.Start/graphBucket().Seconds()), buckets-1)
+			counts[bucket]++
 		}
 	}
+	return counts
+}
 
-	items := make([]opts.BarData, mins)
+// minLastBin is the fraction of a bin the last bin of a graph must last for
+// its count to be scaled to a full bin. Shorter ones have too few words to
+// tell the pace, and scaled up they would only show a spike.
+const minLastBin = 0.25
+
+// wordRates returns wordCounts scaled to the width of the bins, so the last
+// bin, which is shorter unless r lasts a whole number of bins, shows how fast
+// its words were said instead of looking like a drop in the pace. Last bins
+// shorter than minLastBin of a bin are left as they are.
+func wordRates(r *interfacesv1.PreRecordedResponse) []int {
+	counts := wordCounts(r)
+	last := len(counts) - 1
+	width := graphBucket().Seconds()
+	if remaining := r.Metadata.Duration - float64(last)*width; remaining >= minLastBin*width && remaining < width {
+		counts[last] = int(math.Round(float64(counts[last]) * width / remaining))
+	}
+	return counts
+}
+
+func generateWordCountSeries(r *interfacesv1.PreRecordedResponse) []opts.BarData {
+	counts := wordRates(r)
+	items := make([]opts.BarData, len(counts))
 	for i, c := range counts {
 		items[i] = opts.BarData{Value: c}
 	}
@@ -364,40 +1134,101 @@ func generateWordCountSeries(r *interfacesv1.PreRecordedResponse) []opts.BarData
 	return items
 }
 
-func generateMinutesSeries(r *interfacesv1.PreRecordedResponse) []int {
-	mins := int(math.Trunc(r.Metadata.Duration/60) + 1)
-	items := make([]int, 0)
-	for i := 0; i < mins; i++ {
-		items = append(items, i)
+// generateCumulativeSeries returns how many words of r were said up to the
+// end of each bin of its graph, for the line and area graphs.
+func generateCumulativeSeries(r *interfacesv1.PreRecordedResponse) []opts.LineData {
+	counts := wordCounts(r)
+	items := make([]opts.LineData, len(counts))
+	total := 0
+	for i, c := range counts {
+		total += c
+		items[i] = opts.LineData{Value: total}
 	}
 	return items
 }
 
-func CreateGraph(r *interfacesv1.PreRecordedResponse, file FilePath) error {
+// generateMinutesSeries returns the labels of the bins of the graph of r,
+// the time each of them starts at.
+func generateMinutesSeries(r *interfacesv1.PreRecordedResponse) []string {
+	items := make([]string, 0)
+	for i := 0; i < graphBuckets(r); i++ {
+		items = append(items, captions.Clock(float64(i)*graphBucket().Seconds()))
+	}
+	return items
+}
 
-	bar := charts.NewBar()
-	bar.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
-		Title: string(file),
-	}))
+// graphFormats are the formats --graph-format can write graphs in.
+var graphFormats = []string{"html", "png", "svg"}
 
-	bar.SetXAxis(generateMinutesSeries(r)).
-		AddSeries("Words", generateWordCountSeries(r))
+// graphTypes are the charts --graph-type can draw. Line and area charts add
+// up the words over time, so changes of pace show as changes of slope.
+var graphTypes = []string{"bar", "line", "area"}
+
+// graphPath returns the path of the graph of file.
+func graphPath(file FilePath) string {
+	return filepath.Join(graphsDir(file), file.OutputName()+"_graph."+cfg.GetString("graph-format"))
+}
 
-	dir := filepath.Join(file.Dir(), graphsDirectory)
+// CreateGraph writes the graph of the words of r over time, as an interactive
+// HTML page or, with --graph-format, as a static PNG or SVG image. The HTML
+// page has the chart of --graph-type, the images are always bar charts.
+func CreateGraph(r *interfacesv1.PreRecordedResponse, file FilePath) error {
+	dir := graphsDir(file)
 	err := os.MkdirAll(dir, os.ModePerm)
 	if err != nil {
 		return fmt.Errorf("creating graphs directory: %w", err)
 	}
 
-	f, err := os.Create(filepath.Join(dir, file.Base()+"_graph.html"))
+	f, err := os.Create(graphPath(file))
 	if err != nil {
 		return fmt.Errorf("creating graph file: %w", err)
 	}
-	err = bar.Render(f)
+	defer f.Close()
+
+	series := "Words per " + bucketName(graphBucket())
+	switch cfg.GetString("graph-format") {
+	case "png", "svg":
+		bar := chart.Bar{
+			Title:  string(file),
+			Series: series,
+			Labels: generateMinutesSeries(r),
+			Values: wordRates(r),
+		}
+		if cfg.GetString("graph-format") == "png" {
+			err = bar.PNG(f)
+		} else {
+			err = bar.SVG(f)
+		}
+	default:
+		if cfg.GetString("graph-type") != "bar" {
+			line := charts.NewLine()
+			line.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
+				Title: string(file),
+			}))
+
+			var area []charts.SeriesOpts
+			if cfg.GetString("graph-type") == "area" {
+				area = append(area, charts.WithAreaStyleOpts(opts.AreaStyle{Opacity: 0.4}))
+			}
+			line.SetXAxis(generateMinutesSeries(r)).
+				AddSeries("Words so far", generateCumulativeSeries(r), area...)
+			err = line.Render(f)
+			break
+		}
+
+		bar := charts.NewBar()
+		bar.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
+			Title: string(file),
+		}))
+
+		bar.SetXAxis(generateMinutesSeries(r)).
+			AddSeries(series, generateWordCountSeries(r))
+		err = bar.Render(f)
+	}
 	if err != nil {
 		return fmt.Errorf("rendering graph: %w", err)
 	}
-	return nil
+	return f.Close()
 }
 
 func GetCmd(config *config.Config) *cobra.Command {
@@ -0,0 +1,54 @@
+package transcribe
+
+import (
+	"dgram/lib/config"
+	"slices"
+	"testing"
+)
+
+func TestMediaExt(t *testing.T) {
+	cfg = config.NewConfig("dgram")
+
+	tests := []struct {
+		file  string
+		ext   string
+		video bool
+		audio bool
+	}{
+		{file: "talk.mp4", ext: ".mp4", video: true},
+		{file: "VIDEO.MOV", ext: ".mov", video: true},
+		{file: "dir/clip.MP4", ext: ".mp4", video: true},
+		{file: "movie.Mkv", ext: ".mkv", video: true},
+		{file: "clip.MP3", ext: ".mp3", audio: true},
+		{file: "voice.Flac", ext: ".flac", audio: true},
+		{file: "notes.TXT", ext: ".txt"},
+		{file: "README", ext: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			fp := FilePath(tt.file)
+			ext := mediaExt(fp)
+			if ext != tt.ext {
+				t.Errorf("mediaExt(%q) = %q, want %q", tt.file, ext, tt.ext)
+			}
+			if video := slices.Contains(VideoExtensions, ext); video != tt.video {
+				t.Errorf("%q is a video: %v, want %v", tt.file, video, tt.video)
+			}
+			if audio := slices.Contains(AudioExtensions, ext); audio != tt.audio {
+				t.Errorf("%q is audio: %v, want %v", tt.file, audio, tt.audio)
+			}
+			if media := isMedia(tt.file); media != (tt.video || tt.audio) {
+				t.Errorf("isMedia(%q) = %v, want %v", tt.file, media, tt.video || tt.audio)
+			}
+		})
+	}
+}
+
+func TestMediaExtInputFormat(t *testing.T) {
+	cfg = config.NewConfig("dgram")
+	cfg.Set("input-format", ".MKV")
+
+	if ext := mediaExt("recording"); ext != ".mkv" {
+		t.Errorf("mediaExt with --input-format .MKV = %q, want %q", ext, ".mkv")
+	}
+}
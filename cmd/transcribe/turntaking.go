@@ -0,0 +1,121 @@
+package transcribe
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+)
+
+// turnTaking are the turn-taking metrics of a file, computed from its
+// diarized utterances. A turn is a run of consecutive utterances of the same
+// speaker.
+type turnTaking struct {
+	Speakers map[string]speakerTurns `json:"speakers"`
+	// Interruptions counts the turns that start before the previous turn ends.
+	Interruptions int `json:"interruptions"`
+	// AverageGap is the mean silence between the end of a turn and the start
+	// of the next one, leaving out interruptions.
+	AverageGap float64 `json:"average_gap_seconds"`
+}
+
+type speakerTurns struct {
+	Turns         int     `json:"turns"`
+	SpeakingTime  float64 `json:"speaking_time_seconds"`
+	AverageTurn   float64 `json:"average_turn_seconds"`
+	Interruptions int     `json:"interruptions"`
+}
+
+// computeTurnTaking returns the turn-taking metrics of r, or nil if r has no
+// diarized utterances.
+func computeTurnTaking(r *interfacesv1.PreRecordedResponse) *turnTaking {
+	r, label := labeledSpeakers(r)
+	if r.Results == nil {
+		return nil
+	}
+
+	utterances := slices.Clone(r.Results.Utterances)
+	utterances = slices.DeleteFunc(utterances, func(u interfacesv1.Utterance) bool { return u.Speaker == nil })
+	if len(utterances) == 0 {
+		return nil
+	}
+	slices.SortStableFunc(utterances, func(a, b interfacesv1.Utterance) int {
+		switch {
+		case a.Start < b.Start:
+			return -1
+		case a.Start > b.Start:
+			return 1
+		}
+		return 0
+	})
+
+	type turn struct {
+		speaker    int
+		start, end float64
+	}
+	var turns []turn
+	for _, u := range utterances {
+		last := len(turns) - 1
+		if last >= 0 && turns[last].speaker == *u.Speaker {
+			turns[last].end = max(turns[last].end, u.End)
+			continue
+		}
+		turns = append(turns, turn{speaker: *u.Speaker, start: u.Start, end: u.End})
+	}
+
+	t := &turnTaking{Speakers: make(map[string]speakerTurns)}
+	var gaps []float64
+	for i, tu := range turns {
+		s := t.Speakers[label(tu.speaker)]
+		s.Turns++
+		s.SpeakingTime += tu.end - tu.start
+		if i > 0 {
+			if gap := tu.start - turns[i-1].end; gap < 0 {
+				s.Interruptions++
+				t.Interruptions++
+			} else {
+				gaps = append(gaps, gap)
+			}
+		}
+		t.Speakers[label(tu.speaker)] = s
+	}
+
+	for name, s := range t.Speakers {
+		s.AverageTurn = roundMillis(s.SpeakingTime / float64(s.Turns))
+		s.SpeakingTime = roundMillis(s.SpeakingTime)
+		t.Speakers[name] = s
+	}
+	if len(gaps) > 0 {
+		total := 0.0
+		for _, g := range gaps {
+			total += g
+		}
+		t.AverageGap = roundMillis(total / float64(len(gaps)))
+	}
+	return t
+}
+
+// roundMillis rounds seconds to the millisecond, the precision of Deepgram's
+// timestamps.
+func roundMillis(seconds float64) float64 {
+	return math.Round(seconds*1000) / 1000
+}
+
+// writeTurnTaking writes turntaking.json with the turn-taking metrics of each
+// file.
+func writeTurnTaking(metrics map[string]*turnTaking) error {
+	data, err := json.MarshalIndent(metrics, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling turn-taking metrics: %w", err)
+	}
+
+	path := summaryPath("turntaking.json")
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package transcribe
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits are the units accepted by --max-upload-size, longest suffix first.
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1000 * 1000 * 1000},
+	{"MB", 1000 * 1000},
+	{"KB", 1000},
+	{"B", 1},
+}
+
+// parseSize parses sizes like "2GB", "500MB" or "1024".
+func parseSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.bytes
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// formatSize formats a number of bytes for humans.
+func formatSize(bytes int64) string {
+	for _, unit := range sizeUnits {
+		if bytes >= unit.bytes && unit.bytes > 1 {
+			return fmt.Sprintf("%.1f%s", float64(bytes)/float64(unit.bytes), unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%dB", bytes)
+}
+
+// maxUploadSize returns the largest audio file, in bytes, that is sent to
+// Deepgram, or 0 if there is no limit.
+func maxUploadSize() (int64, error) {
+	size, err := parseSize(cfg.GetString("max-upload-size"))
+	if err != nil {
+		return 0, fmt.Errorf("parsing --max-upload-size: %w", err)
+	}
+	return size, nil
+}
+
+// checkUploadSize fails when audio is larger than --max-upload-size, before
+// spending time uploading a file Deepgram would reject. Files are always sent
+// whole, dgram doesn't split them into chunks.
+func checkUploadSize(audio FilePath) error {
+	limit, err := maxUploadSize()
+	if err != nil || limit == 0 {
+		return err
+	}
+	if isURL(string(audio)) {
+		// the size of remote files is only known to Deepgram
+		return nil
+	}
+
+	info, err := os.Stat(string(audio))
+	if err != nil {
+		return fmt.Errorf("checking size of %q: %w", audio, err)
+	}
+
+	if info.Size() > limit {
+		return fmt.Errorf("file too large: %q is %s, over the %s upload limit; split it into smaller files or raise --max-upload-size", audio, formatSize(info.Size()), formatSize(limit))
+	}
+	return nil
+}
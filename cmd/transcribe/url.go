@@ -0,0 +1,35 @@
+package transcribe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// urlHashLength is how many characters of the hash of a URL go in the names
+// of its outputs.
+const urlHashLength = 8
+
+// isURL reports whether file is the http(s) URL of a remote file, which is
+// sent to Deepgram to fetch instead of being read.
+func isURL(file string) bool {
+	return strings.HasPrefix(file, "http://") || strings.HasPrefix(file, "https://")
+}
+
+// urlHash returns the hash the transcripts of the remote file at u are cached
+// by.
+func urlHash(u string) string {
+	h := sha256.Sum256([]byte(u))
+	return hex.EncodeToString(h[:])
+}
+
+// urlName returns the name of the file at u, from its path without the query.
+func urlName(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil || path.Base(parsed.Path) == "/" || path.Base(parsed.Path) == "." {
+		return "remote"
+	}
+	return path.Base(parsed.Path)
+}
@@ -0,0 +1,63 @@
+package transcribe
+
+import (
+	"dgram/lib/captions"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// expectedArtifacts returns the files processing file should have produced,
+// given the flags of the run.
+func expectedArtifacts(file FilePath) []string {
+	switch {
+	case cfg.GetBool("transcript-only"):
+		if cfg.GetBool("no-cache") {
+			return nil
+		}
+		return []string{string(transcriptPath(file))}
+	case cfg.GetBool("stdout"), cfg.GetBool("print-json"), cfg.GetBool("summary-only"):
+		return nil
+	}
+
+	var paths []string
+	for _, format := range formats() {
+		paths = append(paths, captionPath(file, format))
+	}
+	if len(cfg.GetStringSlice("clip-around")) > 0 {
+		paths = append(paths, clipsPath(file), rangesPath(file))
+	}
+	return paths
+}
+
+// verifyArtifacts checks that every file produced its expected artifacts, and
+// that none of them is empty, other than the clips of files without mentions
+// of the --clip-around keywords. It returns the problems found for each file
+// that has any.
+func verifyArtifacts(files []string) map[string][]string {
+	problems := make(map[string][]string)
+	for _, file := range files {
+		for _, path := range expectedArtifacts(FilePath(file)) {
+			info, err := os.Stat(path)
+			switch {
+			case err != nil:
+				problems[file] = append(problems[file], fmt.Sprintf("missing %s", path))
+			case info.Size() == 0 && !(path == clipsPath(FilePath(file)) && noClips(FilePath(file))):
+				problems[file] = append(problems[file], fmt.Sprintf("empty %s", path))
+			}
+		}
+	}
+	return problems
+}
+
+// noClips reports whether the ranges of file around the --clip-around
+// keywords were written and there are none, which leaves its clips empty.
+func noClips(file FilePath) bool {
+	data, err := os.ReadFile(rangesPath(file))
+	if err != nil {
+		return false
+	}
+	var ranges []captions.Range
+	err = json.Unmarshal(data, &ranges)
+	return err == nil && len(ranges) == 0
+}
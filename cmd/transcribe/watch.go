@@ -0,0 +1,166 @@
+package transcribe
+
+import (
+	"context"
+	"dgram/lib/fsys"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest"
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchSettle is how long a new file must go without changes before --watch
+// considers it complete.
+const watchSettle = 2 * time.Second
+
+// watch transcribes the media files that appear in dirs, or in any of their
+// subdirectories, until ctx is done or stopping is closed. Files being
+// processed when it stops are finished first.
+func watch(ctx context.Context, stopping <-chan struct{}, dg *api.Client, dirs []string, workers int) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("watching %q: %w", dir, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("--watch needs directories, %q isn't one", dir)
+		}
+		err = watchTree(watcher, dir)
+		if err != nil {
+			return err
+		}
+	}
+	infof("Watching %s for new files, interrupt to stop\n", strings.Join(dirs, ", "))
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	slots := make(chan struct{}, workers)
+
+	// pending are the files seen changing, with the time of their last change
+	pending := make(map[string]time.Time)
+	// running are the files being processed. Files that change again while
+	// they're processed stay pending until they're done, so each file is
+	// processed by one worker at a time.
+	var mu sync.Mutex
+	running := make(map[string]bool)
+	ticker := time.NewTicker(watchSettle / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-stopping:
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			logf("Watching for new files: %v\n", err)
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) || watchIgnored(event.Name) {
+				continue
+			}
+			info, err := os.Stat(event.Name)
+			if err != nil {
+				// gone already, like the temporary files of downloads
+				continue
+			}
+			if info.IsDir() {
+				err := watchTree(watcher, event.Name)
+				if err != nil {
+					logf("%v\n", err)
+				}
+				continue
+			}
+			if isMedia(event.Name) {
+				pending[event.Name] = time.Now()
+			}
+		case now := <-ticker.C:
+			for file, changed := range pending {
+				if now.Sub(changed) < watchSettle || fsys.IsBeingDownloaded(file) {
+					continue
+				}
+				mu.Lock()
+				busy := running[file]
+				if !busy {
+					running[file] = true
+				}
+				mu.Unlock()
+				if busy {
+					continue
+				}
+				delete(pending, file)
+
+				select {
+				case slots <- struct{}{}:
+				case <-ctx.Done():
+					return nil
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer func() { <-slots }()
+					defer func() {
+						mu.Lock()
+						delete(running, file)
+						mu.Unlock()
+					}()
+					result := processJob(ctx, dg, file)
+					if result.Error != nil {
+						logf("Error processing %q: %v\n", file, result.Error)
+					}
+				}()
+			}
+		}
+	}
+}
+
+// watchTree watches dir and its subdirectories, except the ones dgram writes
+// its own files to.
+func watchTree(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if watchIgnored(path) {
+			return filepath.SkipDir
+		}
+		err = watcher.Add(path)
+		if err != nil {
+			return fmt.Errorf("watching %q: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// watchIgnored reports whether path is in one of the directories dgram writes
+// to, see outputDirNames, whose files would otherwise be transcribed in a
+// loop.
+func watchIgnored(path string) bool {
+	p := "/" + filepath.ToSlash(filepath.Clean(path)) + "/"
+	for _, name := range outputDirNames() {
+		if strings.Contains(p, "/"+filepath.ToSlash(filepath.Clean(name))+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -5,9 +5,11 @@ go 1.24.0
 require (
 	github.com/andrerfcsantos/deepgram-go-captions v0.1.0
 	github.com/deepgram/deepgram-go-sdk v1.8.2
+	github.com/fsnotify/fsnotify v1.8.0
 	github.com/go-echarts/go-echarts/v2 v2.5.0
 	github.com/muesli/go-app-paths v0.2.2
 	github.com/spf13/cobra v1.8.1
+	github.com/spf13/pflag v1.0.6
 	github.com/spf13/viper v1.19.0
 	github.com/u2takey/ffmpeg-go v0.5.0
 )
@@ -16,7 +18,6 @@ require (
 	github.com/aws/aws-sdk-go v1.55.6 // indirect
 	github.com/dvonthenen/websocket v1.5.1-dyv.2 // indirect
 	github.com/fatih/color v1.15.0 // indirect
-	github.com/fsnotify/fsnotify v1.8.0 // indirect
 	github.com/go-logr/logr v1.4.2 // indirect
 	github.com/gorilla/schema v1.3.0 // indirect
 	github.com/hashicorp/hcl v1.0.0 // indirect
@@ -34,7 +35,6 @@ require (
 	github.com/sourcegraph/conc v0.3.0 // indirect
 	github.com/spf13/afero v1.12.0 // indirect
 	github.com/spf13/cast v1.7.1 // indirect
-	github.com/spf13/pflag v1.0.6 // indirect
 	github.com/subosito/gotenv v1.6.0 // indirect
 	github.com/u2takey/go-utils v0.3.1 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
@@ -0,0 +1,37 @@
+package captions
+
+import (
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+)
+
+// WithAlternative returns a copy of r where alternative n of every channel
+// comes first, so everything reading the first alternative reads it instead.
+// Deepgram makes the utterances from the first alternative only, so for other
+// alternatives they are dropped, and the outputs made from the words and
+// paragraphs of the alternative instead. It returns r and false when some channel doesn't have alternative n.
+func WithAlternative(r *interfacesv1.PreRecordedResponse, n int) (*interfacesv1.PreRecordedResponse, bool) {
+	if n == 0 {
+		return r, true
+	}
+	if r.Results == nil || n < 0 {
+		return r, false
+	}
+
+	channels := make([]interfacesv1.Channel, len(r.Results.Channels))
+	for i, c := range r.Results.Channels {
+		if n >= len(c.Alternatives) {
+			return r, false
+		}
+		alternatives := []interfacesv1.Alternative{c.Alternatives[n]}
+		alternatives = append(alternatives, c.Alternatives[:n]...)
+		c.Alternatives = append(alternatives, c.Alternatives[n+1:]...)
+		channels[i] = c
+	}
+
+	results := *r.Results
+	results.Channels = channels
+	results.Utterances = nil
+	chosen := *r
+	chosen.Results = &results
+	return &chosen, true
+}
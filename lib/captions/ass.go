@@ -0,0 +1,108 @@
+package captions
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+)
+
+// assHeader is the start of every ASS file, up to its styles, for a 1080p
+// video.
+const assHeader = `[Script Info]
+ScriptType: v4.00+
+PlayResX: 1920
+PlayResY: 1080
+WrapStyle: 0
+ScaledBorderAndShadow: yes
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+`
+
+const assEvents = `
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+`
+
+// assColors are the text colors given to the speakers in turn, as ASS writes
+// them, &HAABBGGRR: white, yellow, cyan, green, magenta and orange.
+var assColors = []string{"&H00FFFFFF", "&H0000FFFF", "&H00FFFF00", "&H0000FF00", "&H00FF00FF", "&H0000A5FF"}
+
+// ASS renders r as Advanced SubStation Alpha subtitles, with a dialogue line
+// per sentence of its paragraphs or, when paragraphs weren't requested, per
+// utterance. Each speaker gets a style of its own with a different color,
+// and is named by label.
+func ASS(r *interfacesv1.PreRecordedResponse, label func(speaker int) string) string {
+	type dialogue struct {
+		start, end float64
+		speaker    *int
+		text       string
+	}
+
+	var lines []dialogue
+	if p := paragraphs(r); len(p) > 0 {
+		for _, p := range p {
+			for _, s := range p.Sentences {
+				lines = append(lines, dialogue{s.Start, s.End, p.Speaker, s.Text})
+			}
+		}
+	} else if r.Results != nil {
+		for _, u := range r.Results.Utterances {
+			lines = append(lines, dialogue{u.Start, u.End, u.Speaker, u.Transcript})
+		}
+	}
+
+	var speakers []int
+	for _, l := range lines {
+		if l.speaker != nil && !slices.Contains(speakers, *l.speaker) {
+			speakers = append(speakers, *l.speaker)
+		}
+	}
+	slices.Sort(speakers)
+
+	var b strings.Builder
+	b.WriteString(assHeader)
+	b.WriteString(assStyle("Default", assColors[0]))
+	for i, s := range speakers {
+		b.WriteString(assStyle(assStyleName(s), assColors[i%len(assColors)]))
+	}
+
+	b.WriteString(assEvents)
+	for _, l := range lines {
+		style, name := "Default", ""
+		if l.speaker != nil {
+			style, name = assStyleName(*l.speaker), label(*l.speaker)
+		}
+		fmt.Fprintf(&b, "Dialogue: 0,%s,%s,%s,%s,0,0,0,,%s\n", assTime(l.start), assTime(l.end), style, assField(name), assText(l.text))
+	}
+	return b.String()
+}
+
+func assStyle(name, color string) string {
+	return fmt.Sprintf("Style: %s,Arial,56,%s,&H000000FF,&H00000000,&H80000000,0,0,0,0,100,100,0,0,1,2,1,2,60,60,50,1\n", name, color)
+}
+
+func assStyleName(speaker int) string {
+	return fmt.Sprintf("Speaker%d", speaker)
+}
+
+// assTime formats seconds as ASS timestamps, h:mm:ss.cs, like 0:01:03.25.
+func assTime(seconds float64) string {
+	cs := int(math.Round(seconds * 100))
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
+
+// assField drops the commas of a field other than the text, which would
+// start the next field.
+func assField(s string) string {
+	return strings.ReplaceAll(s, ",", "")
+}
+
+// assText escapes the braces of s, which start override tags, and writes its
+// line breaks as ASS hard breaks.
+func assText(s string) string {
+	return strings.NewReplacer("{", `\{`, "}", `\}`, "\n", `\N`).Replace(strings.TrimSpace(s))
+}
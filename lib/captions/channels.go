@@ -0,0 +1,156 @@
+package captions
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+)
+
+// channelStride separates the speakers of different channels in the speaker
+// numbers given by ChannelSpeakers.
+const channelStride = 1000
+
+// Multichannel reports whether r was transcribed with one result per audio
+// channel.
+func Multichannel(r *interfacesv1.PreRecordedResponse) bool {
+	return r.Results != nil && len(r.Results.Channels) > 1
+}
+
+// ChannelSpeakers returns a copy of r where the speaker of every utterance, and
+// of its words, also identifies the channel the utterance is in. Deepgram
+// numbers the speakers of each channel from 0, so without this speaker 1 of
+// channel 0 and speaker 1 of channel 1 would be the same speaker once the
+// channels are rendered together. Use ChannelLabel to name the speakers.
+//
+// The channels of the copy are merged into one, with the words and paragraphs
+// of every channel interleaved by time, so what's rendered from the first
+// channel has the contents of all of them.
+func ChannelSpeakers(r *interfacesv1.PreRecordedResponse) *interfacesv1.PreRecordedResponse {
+	if r.Results == nil {
+		return r
+	}
+
+	results := *r.Results
+	results.Utterances = make([]interfacesv1.Utterance, len(r.Results.Utterances))
+	for i, u := range r.Results.Utterances {
+		u.Speaker = channelSpeaker(u.Channel, u.Speaker)
+		u.Words = make([]interfacesv1.Word, len(r.Results.Utterances[i].Words))
+		for j, w := range r.Results.Utterances[i].Words {
+			w.Speaker = channelSpeaker(u.Channel, w.Speaker)
+			u.Words[j] = w
+		}
+		results.Utterances[i] = u
+	}
+	if len(r.Results.Channels) > 1 {
+		mergeChannels(&results)
+	}
+
+	labeled := *r
+	labeled.Results = &results
+	return &labeled
+}
+
+// mergeChannels replaces the channels of results with a single one, holding
+// the words and paragraphs of the first alternative of each channel sorted by
+// their start. Topics, which point at the words of the first channel, are
+// moved to where those words end up.
+func mergeChannels(results *interfacesv1.Result) {
+	type channelWord struct {
+		interfacesv1.Word
+		channel, index int
+	}
+
+	var words []channelWord
+	var paragraphs []interfacesv1.Paragraph
+	hasParagraphs := false
+	for c, channel := range results.Channels {
+		if len(channel.Alternatives) == 0 {
+			continue
+		}
+		a := channel.Alternatives[0]
+		for i, w := range a.Words {
+			w.Speaker = channelSpeaker(c, w.Speaker)
+			words = append(words, channelWord{Word: w, channel: c, index: i})
+		}
+		if a.Paragraphs != nil {
+			hasParagraphs = true
+			for _, p := range a.Paragraphs.Paragraphs {
+				p.Speaker = channelSpeaker(c, p.Speaker)
+				paragraphs = append(paragraphs, p)
+			}
+		}
+	}
+	slices.SortStableFunc(words, func(a, b channelWord) int { return cmp.Compare(a.Start, b.Start) })
+	slices.SortStableFunc(paragraphs, func(a, b interfacesv1.Paragraph) int { return cmp.Compare(a.Start, b.Start) })
+
+	merged := interfacesv1.Alternative{Words: make([]interfacesv1.Word, len(words))}
+	// moved maps the index of each word of the first channel to its new one
+	moved := make(map[int]int)
+	for i, w := range words {
+		merged.Words[i] = w.Word
+		if w.channel == 0 {
+			moved[w.index] = i
+		}
+	}
+	merged.Transcript = wordsText(merged.Words)
+
+	if hasParagraphs {
+		text := make([]string, len(paragraphs))
+		for i, p := range paragraphs {
+			text[i] = paragraphText(p)
+		}
+		merged.Paragraphs = &interfacesv1.Paragraphs{Transcript: strings.Join(text, "\n\n"), Paragraphs: paragraphs}
+	}
+
+	channel := results.Channels[0]
+	channel.Alternatives = []interfacesv1.Alternative{merged}
+	results.Channels = []interfacesv1.Channel{channel}
+
+	if results.Topics != nil {
+		topics := *results.Topics
+		topics.Segments = make([]interfacesv1.Segment, len(results.Topics.Segments))
+		for i, s := range results.Topics.Segments {
+			s.StartWord, s.EndWord = moved[s.StartWord], moved[s.EndWord]
+			topics.Segments[i] = s
+		}
+		results.Topics = &topics
+	}
+}
+
+func channelSpeaker(channel int, speaker *int) *int {
+	if speaker == nil {
+		return nil
+	}
+	s := channel*channelStride + *speaker
+	return &s
+}
+
+// ChannelLabel names a speaker numbered by ChannelSpeakers after its channel
+// and its speaker within the channel, like "C0-S1".
+func ChannelLabel(speaker int) string {
+	return fmt.Sprintf("C%d-S%d", speaker/channelStride, speaker%channelStride)
+}
+
+var (
+	srtSpeakerRe = regexp.MustCompile(`(?m)^\[speaker (\d+)\]$`)
+	vttSpeakerRe = regexp.MustCompile(`(?m)^<v Speaker (\d+)>`)
+)
+
+// RelabelSpeakers replaces the speakers of the SRT or WebVTT captions out with
+// the speaker names given by label. SRT "[speaker N]" lines become
+// "[speaker <label>]" and WebVTT "<v Speaker N>" voices become "<v <label>>".
+func RelabelSpeakers(out string, label func(speaker int) string) string {
+	out = srtSpeakerRe.ReplaceAllStringFunc(out, func(line string) string {
+		speaker, _ := strconv.Atoi(srtSpeakerRe.FindStringSubmatch(line)[1])
+		return "[speaker " + label(speaker) + "]"
+	})
+	return vttSpeakerRe.ReplaceAllStringFunc(out, func(voice string) string {
+		speaker, _ := strconv.Atoi(vttSpeakerRe.FindStringSubmatch(voice)[1])
+		return "<v " + label(speaker) + ">"
+	})
+}
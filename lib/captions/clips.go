@@ -0,0 +1,150 @@
+package captions
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/andrerfcsantos/deepgram-go-captions/converters"
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+)
+
+// Range is a span of a transcript, in seconds, around one or more keyword
+// hits.
+type Range struct {
+	Start    float64  `json:"start"`
+	End      float64  `json:"end"`
+	Keywords []string `json:"keywords"`
+}
+
+// KeywordRanges finds every mention of keywords in the channels of r and
+// returns the spans from window seconds before each mention to window seconds
+// after it, in chronological order. Overlapping spans are merged. Keywords are
+// matched case-insensitively and may have more than one word.
+func KeywordRanges(r *interfacesv1.PreRecordedResponse, keywords []string, window float64) []Range {
+	if r.Results == nil {
+		return nil
+	}
+
+	var hits []Range
+	for _, channel := range r.Results.Channels {
+		if len(channel.Alternatives) == 0 {
+			continue
+		}
+		// phrases are matched within a channel, where the words of a speaker
+		// aren't interleaved with the ones of the others
+		words := channel.Alternatives[0].Words
+		for i := range words {
+			for _, keyword := range keywords {
+				phrase := strings.Fields(normalize(keyword))
+				if len(phrase) == 0 || i+len(phrase) > len(words) || !matches(words[i:i+len(phrase)], phrase) {
+					continue
+				}
+				hits = append(hits, Range{
+					Start:    millis(max(words[i].Start-window, 0)),
+					End:      millis(words[i+len(phrase)-1].End + window),
+					Keywords: []string{keyword},
+				})
+			}
+		}
+	}
+	slices.SortStableFunc(hits, func(a, b Range) int { return cmp.Compare(a.Start, b.Start) })
+
+	var ranges []Range
+	for _, hit := range hits {
+		last := len(ranges) - 1
+		if last >= 0 && hit.Start <= ranges[last].End {
+			ranges[last].End = max(ranges[last].End, hit.End)
+			if !containsFold(ranges[last].Keywords, hit.Keywords[0]) {
+				ranges[last].Keywords = append(ranges[last].Keywords, hit.Keywords[0])
+			}
+			continue
+		}
+		ranges = append(ranges, hit)
+	}
+	return ranges
+}
+
+// millis rounds seconds to the millisecond, the precision of caption
+// timestamps.
+func millis(seconds float64) float64 {
+	return math.Round(seconds*1000) / 1000
+}
+
+func matches(words []interfacesv1.Word, phrase []string) bool {
+	for i, w := range phrase {
+		if normalize(words[i].Word) != w {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize lowercases s and drops its punctuation, the way Deepgram writes
+// the unpunctuated words of a transcript.
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) && r != '\'' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Within is a converter that only keeps the words produced by another
+// converter that start inside one of Ranges. Lines are broken at the edges of
+// each range, so a cue never spans two of them.
+type Within struct {
+	Converter converters.Converter
+	Ranges    []Range
+}
+
+func (w Within) Convert() (converters.Worder, error) {
+	worder, err := w.Converter.Convert()
+	if err != nil {
+		return nil, err
+	}
+
+	var lines [][]converters.TimedWord
+	for _, line := range worder.Lines() {
+		var kept []converters.TimedWord
+		current := -1
+		for _, word := range line {
+			r := w.rangeOf(word.Start)
+			if r != current && len(kept) > 0 {
+				lines = append(lines, kept)
+				kept = nil
+			}
+			current = r
+			if r >= 0 {
+				kept = append(kept, word)
+			}
+		}
+		if len(kept) > 0 {
+			lines = append(lines, kept)
+		}
+	}
+
+	return converters.NewBasicWorder(converters.WithLines(lines)), nil
+}
+
+// rangeOf returns the index of the range containing t, or -1 if there's none.
+func (w Within) rangeOf(t float64) int {
+	for i, r := range w.Ranges {
+		if t >= r.Start && t <= r.End {
+			return i
+		}
+	}
+	return -1
+}
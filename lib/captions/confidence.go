@@ -0,0 +1,139 @@
+package captions
+
+import (
+	"strings"
+
+	"github.com/andrerfcsantos/deepgram-go-captions/converters"
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+)
+
+// LowConfidence is a converter that wraps the words produced by another
+// converter in question marks, like ?word?, when Deepgram's confidence in them
+// is below Threshold, so reviewers know where to look.
+type LowConfidence struct {
+	Converter converters.Converter
+	Threshold float64
+}
+
+func (l LowConfidence) Convert() (converters.Worder, error) {
+	worder, err := l.Converter.Convert()
+	if err != nil {
+		return nil, err
+	}
+
+	var lines [][]converters.TimedWord
+	for _, line := range worder.Lines() {
+		marked := make([]converters.TimedWord, len(line))
+		for i, w := range line {
+			if w.HasConfidence() && w.GetConfidence() < l.Threshold {
+				w.Word = "?" + w.Word + "?"
+				if w.HasPunctuatedWord() {
+					w.SetPunctuatedWord("?" + w.GetPunctuatedWord() + "?")
+				}
+			}
+			marked[i] = w
+		}
+		lines = append(lines, marked)
+	}
+
+	return converters.NewBasicWorder(converters.WithLines(lines)), nil
+}
+
+// WithoutLowConfidence returns a copy of r without the words of its first
+// channel Deepgram is less confident about than threshold. The sentences of
+// its paragraphs, and its transcript, are written again from the words left,
+// and sentences left without words are dropped.
+func WithoutLowConfidence(r *interfacesv1.PreRecordedResponse, threshold float64) *interfacesv1.PreRecordedResponse {
+	return withWords(r, func(words []interfacesv1.Word) []interfacesv1.Word {
+		var kept []interfacesv1.Word
+		for _, w := range words {
+			if w.Confidence >= threshold {
+				kept = append(kept, w)
+			}
+		}
+		return kept
+	})
+}
+
+// MarkLowConfidence returns a copy of r where the words of its first channel
+// Deepgram is less confident about than threshold are wrapped in question
+// marks, like ?word?, as LowConfidence does for captions. The sentences of its
+// paragraphs, and its transcript, are written again from the marked words.
+func MarkLowConfidence(r *interfacesv1.PreRecordedResponse, threshold float64) *interfacesv1.PreRecordedResponse {
+	return withWords(r, func(words []interfacesv1.Word) []interfacesv1.Word {
+		marked := make([]interfacesv1.Word, len(words))
+		for i, w := range words {
+			if w.Confidence < threshold {
+				w.Word = "?" + w.Word + "?"
+				if w.PunctuatedWord != "" {
+					w.PunctuatedWord = "?" + w.PunctuatedWord + "?"
+				}
+			}
+			marked[i] = w
+		}
+		return marked
+	})
+}
+
+// withWords returns a copy of r with the words of the first alternative of
+// its first channel replaced by what change makes of them, and its
+// transcript and the sentences of its paragraphs written again from them.
+func withWords(r *interfacesv1.PreRecordedResponse, change func([]interfacesv1.Word) []interfacesv1.Word) *interfacesv1.PreRecordedResponse {
+	if r.Results == nil || len(r.Results.Channels) == 0 || len(r.Results.Channels[0].Alternatives) == 0 {
+		return r
+	}
+
+	a := r.Results.Channels[0].Alternatives[0]
+	words := change(a.Words)
+	a.Words = words
+	a.Transcript = wordsText(words)
+
+	if a.Paragraphs != nil {
+		p := *a.Paragraphs
+		p.Paragraphs = nil
+		for _, para := range a.Paragraphs.Paragraphs {
+			var sentences []interfacesv1.Sentence
+			for _, s := range para.Sentences {
+				var kept []interfacesv1.Word
+				for _, w := range words {
+					if w.Start >= s.Start && w.End <= s.End {
+						kept = append(kept, w)
+					}
+				}
+				if len(kept) == 0 {
+					continue
+				}
+				s.Text = wordsText(kept)
+				sentences = append(sentences, s)
+			}
+			if len(sentences) == 0 {
+				continue
+			}
+			para.Sentences = sentences
+			p.Paragraphs = append(p.Paragraphs, para)
+		}
+		a.Paragraphs = &p
+	}
+
+	channel := r.Results.Channels[0]
+	channel.Alternatives = append([]interfacesv1.Alternative{a}, channel.Alternatives[1:]...)
+	results := *r.Results
+	results.Channels = append([]interfacesv1.Channel{channel}, r.Results.Channels[1:]...)
+
+	filtered := *r
+	filtered.Results = &results
+	return &filtered
+}
+
+// wordsText joins the punctuated words of words, or the words themselves when
+// they weren't punctuated.
+func wordsText(words []interfacesv1.Word) string {
+	text := make([]string, len(words))
+	for i, w := range words {
+		text[i] = w.PunctuatedWord
+		if text[i] == "" {
+			text[i] = w.Word
+		}
+	}
+	return strings.Join(text, " ")
+}
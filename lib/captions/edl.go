@@ -0,0 +1,104 @@
+package captions
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+)
+
+// Granularities are the kinds of segments Markers can mark.
+var Granularities = []string{"utterance", "paragraph", "topic"}
+
+// maxMarkerLabel is the length labels are cut at, editors only show the start
+// of long marker names.
+const maxMarkerLabel = 60
+
+// Marker is a labeled span of a transcript, in seconds.
+type Marker struct {
+	Start float64
+	End   float64
+	Label string
+}
+
+// Markers returns a marker for every segment of r of the given granularity,
+// one of Granularities. label names each speaker.
+func Markers(r *interfacesv1.PreRecordedResponse, granularity string, label func(speaker int) string) ([]Marker, error) {
+	if r.Results == nil {
+		return nil, nil
+	}
+
+	turn := func(speaker *int, text string) string {
+		text = strings.TrimSpace(text)
+		if speaker != nil {
+			text = label(*speaker) + ": " + text
+		}
+		return cut(text, maxMarkerLabel)
+	}
+
+	var markers []Marker
+	switch granularity {
+	case "utterance":
+		for _, u := range r.Results.Utterances {
+			markers = append(markers, Marker{Start: u.Start, End: u.End, Label: turn(u.Speaker, u.Transcript)})
+		}
+	case "paragraph":
+		for _, p := range paragraphs(r) {
+			text := ""
+			if len(p.Sentences) > 0 {
+				text = p.Sentences[0].Text
+			}
+			markers = append(markers, Marker{Start: p.Start, End: p.End, Label: turn(p.Speaker, text)})
+		}
+	case "topic":
+		if r.Results.Topics == nil || len(r.Results.Channels) == 0 || len(r.Results.Channels[0].Alternatives) == 0 {
+			return nil, nil
+		}
+		words := r.Results.Channels[0].Alternatives[0].Words
+		for _, s := range r.Results.Topics.Segments {
+			if s.Topics == nil || s.StartWord >= len(words) || s.EndWord >= len(words) {
+				continue
+			}
+			topics := make([]string, 0, len(*s.Topics))
+			for _, t := range *s.Topics {
+				topics = append(topics, t.Topic)
+			}
+			markers = append(markers, Marker{Start: words[s.StartWord].Start, End: words[s.EndWord].End, Label: cut(strings.Join(topics, ", "), maxMarkerLabel)})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported granularity %q, supported granularities are: %s", granularity, strings.Join(Granularities, ", "))
+	}
+	return markers, nil
+}
+
+// EDL renders markers as a CMX 3600 edit decision list, one event per marker
+// with its label as a comment, with timecodes at fps frames per second.
+func EDL(title string, markers []Marker, fps float64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "TITLE: %s\nFCM: NON-DROP FRAME\n\n", title)
+	for i, m := range markers {
+		start, end := Timecode(m.Start, fps), Timecode(m.End, fps)
+		fmt.Fprintf(&b, "%03d  AX       V     C        %s %s %s %s\n", i+1, start, end, start, end)
+		fmt.Fprintf(&b, "* COMMENT: %s\n\n", m.Label)
+	}
+	return b.String()
+}
+
+// Timecode formats seconds as a non-drop-frame hh:mm:ss:ff timecode at fps
+// frames per second.
+func Timecode(seconds, fps float64) string {
+	perSecond := int(math.Round(fps))
+	frames := int(math.Round(seconds * fps))
+	total := frames / perSecond
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", total/3600, total%3600/60, total%60, frames%perSecond)
+}
+
+// cut shortens s to at most n runes, marking the cut with an ellipsis.
+func cut(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
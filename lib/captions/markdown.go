@@ -0,0 +1,88 @@
+package captions
+
+import (
+	"strings"
+
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+)
+
+// Markdown renders r as a Markdown transcript, with a bold header for every
+// speaker turn and its paragraphs as body text. Paragraphs come from the first
+// channel of r, or from its utterances when paragraphs weren't requested. When
+// r has topics, the transcript starts with a table of contents listing them.
+// label names each speaker.
+func Markdown(r *interfacesv1.PreRecordedResponse, label func(speaker int) string) string {
+	if r.Results == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	writeContents(&b, r)
+
+	first := true
+	var current *int
+	turn := func(start float64, speaker *int, text string) {
+		if first || !sameSpeaker(current, speaker) {
+			if speaker != nil {
+				b.WriteString("**" + label(*speaker) + "** ")
+			}
+			b.WriteString("_[" + Clock(start) + "]_\n\n")
+		}
+		first = false
+		current = speaker
+		b.WriteString(strings.TrimSpace(text) + "\n\n")
+	}
+
+	if p := paragraphs(r); len(p) > 0 {
+		for _, p := range p {
+			sentences := make([]string, 0, len(p.Sentences))
+			for _, s := range p.Sentences {
+				sentences = append(sentences, s.Text)
+			}
+			turn(p.Start, p.Speaker, strings.Join(sentences, " "))
+		}
+	} else {
+		for _, u := range r.Results.Utterances {
+			turn(u.Start, u.Speaker, u.Transcript)
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// writeContents writes a table of contents of the topics of r, if it has any.
+func writeContents(b *strings.Builder, r *interfacesv1.PreRecordedResponse) {
+	if r.Results.Topics == nil || len(r.Results.Topics.Segments) == 0 {
+		return
+	}
+
+	var words []interfacesv1.Word
+	if len(r.Results.Channels) > 0 && len(r.Results.Channels[0].Alternatives) > 0 {
+		words = r.Results.Channels[0].Alternatives[0].Words
+	}
+
+	b.WriteString("## Contents\n\n")
+	for _, s := range r.Results.Topics.Segments {
+		if s.Topics == nil || len(*s.Topics) == 0 {
+			continue
+		}
+		topics := make([]string, 0, len(*s.Topics))
+		for _, t := range *s.Topics {
+			topics = append(topics, t.Topic)
+		}
+
+		b.WriteString("- ")
+		if s.StartWord < len(words) {
+			b.WriteString("[" + Clock(words[s.StartWord].Start) + "] ")
+		}
+		b.WriteString(strings.Join(topics, ", ") + "\n")
+	}
+	b.WriteString("\n## Transcript\n\n")
+}
+
+func sameSpeaker(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,59 @@
+package captions
+
+import (
+	"fmt"
+	"strings"
+
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+)
+
+// Minutes renders r as a meeting-minutes style transcript, with one line per
+// speaker turn in chronological order, like "[00:01:03] Alice: ...". Turns
+// come from the utterances of r, or from the paragraphs of its first channel
+// when utterances weren't requested. label names each speaker.
+func Minutes(r *interfacesv1.PreRecordedResponse, label func(speaker int) string) string {
+	var b strings.Builder
+	line := func(start float64, speaker *int, text string) {
+		b.WriteString("[" + Clock(start) + "] ")
+		if speaker != nil {
+			b.WriteString(label(*speaker) + ": ")
+		}
+		b.WriteString(strings.TrimSpace(text) + "\n")
+	}
+
+	if r.Results == nil {
+		return ""
+	}
+
+	if len(r.Results.Utterances) > 0 {
+		for _, u := range r.Results.Utterances {
+			line(u.Start, u.Speaker, u.Transcript)
+		}
+		return b.String()
+	}
+
+	for _, p := range paragraphs(r) {
+		line(p.Start, p.Speaker, paragraphText(p))
+	}
+	return b.String()
+}
+
+// Clock formats seconds as hh:mm:ss.
+func Clock(seconds float64) string {
+	total := int(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, total%3600/60, total%60)
+}
+
+// paragraphs returns the paragraphs of the first alternative of the first
+// channel of r, if any.
+func paragraphs(r *interfacesv1.PreRecordedResponse) []interfacesv1.Paragraph {
+	if r.Results == nil || len(r.Results.Channels) == 0 || len(r.Results.Channels[0].Alternatives) == 0 {
+		return nil
+	}
+
+	p := r.Results.Channels[0].Alternatives[0].Paragraphs
+	if p == nil {
+		return nil
+	}
+	return p.Paragraphs
+}
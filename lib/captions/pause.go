@@ -0,0 +1,36 @@
+package captions
+
+import (
+	"github.com/andrerfcsantos/deepgram-go-captions/converters"
+)
+
+// PauseSplitter is a converter that breaks the lines produced by another
+// converter wherever the silence between two consecutive words is longer than
+// Threshold seconds, so cues end on natural phrase boundaries.
+type PauseSplitter struct {
+	Converter converters.Converter
+	Threshold float64
+}
+
+func (p PauseSplitter) Convert() (converters.Worder, error) {
+	worder, err := p.Converter.Convert()
+	if err != nil {
+		return nil, err
+	}
+
+	var lines [][]converters.TimedWord
+	for _, line := range worder.Lines() {
+		start := 0
+		for i := 1; i < len(line); i++ {
+			if line[i].Start-line[i-1].End > p.Threshold {
+				lines = append(lines, line[start:i])
+				start = i
+			}
+		}
+		if start < len(line) {
+			lines = append(lines, line[start:])
+		}
+	}
+
+	return converters.NewBasicWorder(converters.WithLines(lines)), nil
+}
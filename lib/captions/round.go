@@ -0,0 +1,42 @@
+package captions
+
+import (
+	"math"
+
+	"github.com/andrerfcsantos/deepgram-go-captions/converters"
+)
+
+// Rounder is a converter that rounds the start and end of every word produced
+// by another converter to the nearest multiple of Step seconds, for tools that
+// are picky about the precision of caption timestamps.
+type Rounder struct {
+	Converter converters.Converter
+	Step      float64
+}
+
+func (r Rounder) Convert() (converters.Worder, error) {
+	worder, err := r.Converter.Convert()
+	if err != nil {
+		return nil, err
+	}
+	if r.Step <= 0 {
+		return worder, nil
+	}
+
+	var lines [][]converters.TimedWord
+	for _, line := range worder.Lines() {
+		rounded := make([]converters.TimedWord, len(line))
+		for i, w := range line {
+			w.Start = r.round(w.Start)
+			w.End = max(r.round(w.End), w.Start)
+			rounded[i] = w
+		}
+		lines = append(lines, rounded)
+	}
+
+	return converters.NewBasicWorder(converters.WithLines(lines)), nil
+}
+
+func (r Rounder) round(seconds float64) float64 {
+	return math.Round(seconds/r.Step) * r.Step
+}
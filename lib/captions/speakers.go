@@ -0,0 +1,101 @@
+package captions
+
+import (
+	"strings"
+
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+)
+
+// WithoutSpeakers returns a copy of r without the speakers of its words,
+// paragraphs and utterances, so it renders as if it wasn't diarized.
+func WithoutSpeakers(r *interfacesv1.PreRecordedResponse) *interfacesv1.PreRecordedResponse {
+	if r.Results == nil {
+		return r
+	}
+
+	results := *r.Results
+	results.Channels = make([]interfacesv1.Channel, len(r.Results.Channels))
+	for i, c := range r.Results.Channels {
+		c.Alternatives = make([]interfacesv1.Alternative, len(r.Results.Channels[i].Alternatives))
+		for j, a := range r.Results.Channels[i].Alternatives {
+			a.Words = withoutWordSpeakers(a.Words)
+			if a.Paragraphs != nil {
+				p := *a.Paragraphs
+				p.Paragraphs = make([]interfacesv1.Paragraph, len(a.Paragraphs.Paragraphs))
+				for k, para := range a.Paragraphs.Paragraphs {
+					para.Speaker = nil
+					p.Paragraphs[k] = para
+				}
+				a.Paragraphs = &p
+			}
+			c.Alternatives[j] = a
+		}
+		results.Channels[i] = c
+	}
+
+	results.Utterances = make([]interfacesv1.Utterance, len(r.Results.Utterances))
+	for i, u := range r.Results.Utterances {
+		u.Speaker = nil
+		u.Words = withoutWordSpeakers(u.Words)
+		results.Utterances[i] = u
+	}
+
+	plain := *r
+	plain.Results = &results
+	return &plain
+}
+
+func withoutWordSpeakers(words []interfacesv1.Word) []interfacesv1.Word {
+	plain := make([]interfacesv1.Word, len(words))
+	for i, w := range words {
+		w.Speaker = nil
+		w.SpeakerConfidence = nil
+		plain[i] = w
+	}
+	return plain
+}
+
+// SpeakerTranscripts returns the turns of each speaker of r in order, one per
+// utterance or, when utterances weren't requested, one per run of words of
+// the first channel said by the same speaker. It's empty when r isn't
+// diarized.
+func SpeakerTranscripts(r *interfacesv1.PreRecordedResponse) map[int][]string {
+	turns := make(map[int][]string)
+	if r.Results == nil {
+		return turns
+	}
+
+	if len(r.Results.Utterances) > 0 {
+		for _, u := range r.Results.Utterances {
+			if u.Speaker != nil {
+				turns[*u.Speaker] = append(turns[*u.Speaker], strings.TrimSpace(u.Transcript))
+			}
+		}
+		return turns
+	}
+
+	if len(r.Results.Channels) == 0 || len(r.Results.Channels[0].Alternatives) == 0 {
+		return turns
+	}
+	var turn []string
+	var current *int
+	flush := func() {
+		if current != nil && len(turn) > 0 {
+			turns[*current] = append(turns[*current], strings.Join(turn, " "))
+		}
+		turn = nil
+	}
+	for _, w := range r.Results.Channels[0].Alternatives[0].Words {
+		if !sameSpeaker(current, w.Speaker) {
+			flush()
+			current = w.Speaker
+		}
+		text := w.PunctuatedWord
+		if text == "" {
+			text = w.Word
+		}
+		turn = append(turn, text)
+	}
+	flush()
+	return turns
+}
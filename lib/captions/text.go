@@ -0,0 +1,34 @@
+package captions
+
+import (
+	"strings"
+
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+)
+
+// Text renders r as a plain text transcript, one paragraph per block, from
+// the paragraphs of its first channel or, when paragraphs weren't requested,
+// from its transcript. When r is diarized, each change of speaker starts with
+// the speaker, like "Speaker 0: ...", where label names the speakers.
+func Text(r *interfacesv1.PreRecordedResponse, label func(speaker int) string) string {
+	p := paragraphs(r)
+	if len(p) == 0 {
+		if r.Results == nil || len(r.Results.Channels) == 0 || len(r.Results.Channels[0].Alternatives) == 0 {
+			return ""
+		}
+		return strings.TrimSpace(r.Results.Channels[0].Alternatives[0].Transcript) + "\n"
+	}
+
+	blocks := make([]string, 0, len(p))
+	var current *int
+	for i, p := range p {
+		text := paragraphText(p)
+
+		if p.Speaker != nil && (i == 0 || !sameSpeaker(current, p.Speaker)) {
+			text = label(*p.Speaker) + ": " + text
+		}
+		current = p.Speaker
+		blocks = append(blocks, text)
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
@@ -0,0 +1,31 @@
+package captions
+
+import (
+	"fmt"
+	"strings"
+
+	interfacesv1 "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+)
+
+// TSV renders the paragraphs of the first channel of r as tab-separated
+// values, one "start\tend\ttext" row per paragraph with the times in seconds,
+// like "63.120\t75.480\tWelcome back.". It's empty when paragraphs weren't
+// requested.
+func TSV(r *interfacesv1.PreRecordedResponse) string {
+	var b strings.Builder
+	for _, p := range paragraphs(r) {
+		// tabs and line breaks in the text would start new columns or rows
+		text := strings.Join(strings.Fields(paragraphText(p)), " ")
+		fmt.Fprintf(&b, "%.3f\t%.3f\t%s\n", p.Start, p.End, text)
+	}
+	return b.String()
+}
+
+// paragraphText returns the text of the sentences of p.
+func paragraphText(p interfacesv1.Paragraph) string {
+	sentences := make([]string, 0, len(p.Sentences))
+	for _, s := range p.Sentences {
+		sentences = append(sentences, s.Text)
+	}
+	return strings.Join(sentences, " ")
+}
@@ -0,0 +1,71 @@
+package captions
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andrerfcsantos/deepgram-go-captions/converters"
+)
+
+// LineWrapper is a converter that wraps the words of each line produced by
+// another converter into lines of at most MaxLength characters, and starts a
+// new cue when a cue would have more than MaxLines lines. A word longer than
+// MaxLength gets a line of its own.
+//
+// The renderers of the captions library write each cue on a single line, so
+// the line breaks are put in the words themselves; the output of a renderer
+// should go through TrimWrapped afterwards.
+type LineWrapper struct {
+	Converter converters.Converter
+	MaxLength int
+	MaxLines  int
+}
+
+func (l LineWrapper) Convert() (converters.Worder, error) {
+	worder, err := l.Converter.Convert()
+	if err != nil {
+		return nil, err
+	}
+
+	var cues [][]converters.TimedWord
+	for _, line := range worder.Lines() {
+		var cue []converters.TimedWord
+		lines, length := 0, 0
+		for _, w := range line {
+			n := utf8.RuneCountInString(wordText(w))
+			switch {
+			case len(cue) == 0:
+				lines, length = 1, n
+			case length+1+n <= l.MaxLength:
+				length += 1 + n
+			case lines < l.MaxLines:
+				lines, length = lines+1, n
+				w.SetPunctuatedWord("\n" + wordText(w))
+			default:
+				cues = append(cues, cue)
+				cue = nil
+				lines, length = 1, n
+			}
+			cue = append(cue, w)
+		}
+		if len(cue) > 0 {
+			cues = append(cues, cue)
+		}
+	}
+
+	return converters.NewBasicWorder(converters.WithLines(cues)), nil
+}
+
+// TrimWrapped removes the spaces the renderers leave before the line breaks
+// of LineWrapper from out.
+func TrimWrapped(out string) string {
+	return strings.ReplaceAll(out, " \n", "\n")
+}
+
+// wordText returns the text renderers write for w.
+func wordText(w converters.TimedWord) string {
+	if w.HasPunctuatedWord() {
+		return w.GetPunctuatedWord()
+	}
+	return w.Word
+}
@@ -0,0 +1,135 @@
+// Package chart draws simple bar charts as static images, for reports where
+// the interactive HTML graphs can't be embedded.
+package chart
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
+)
+
+const (
+	width  = 800
+	height = 400
+	// margin is the room around the plot for the title and the axis labels.
+	margin = 50
+	gap    = 2
+)
+
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	axis       = color.RGBA{0x6e, 0x70, 0x79, 0xff}
+	grid       = color.RGBA{0xe0, 0xe6, 0xf1, 0xff}
+	// barColor is the color go-echarts gives the first series.
+	barColor = color.RGBA{0x54, 0x70, 0xc6, 0xff}
+)
+
+// Bar is a bar chart with a bar per label.
+type Bar struct {
+	Title  string
+	Series string
+	Labels []string
+	Values []int
+}
+
+// gridLines is how many horizontal lines divide the plot.
+const gridLines = 4
+
+func (b Bar) max() int {
+	m := 1
+	for _, v := range b.Values {
+		m = max(m, v)
+	}
+	return m
+}
+
+// bars returns the rectangles of the bars, in image coordinates.
+func (b Bar) bars() []image.Rectangle {
+	if len(b.Values) == 0 {
+		return nil
+	}
+
+	plotWidth, plotHeight := width-2*margin, height-2*margin
+	step := float64(plotWidth) / float64(len(b.Values))
+	rects := make([]image.Rectangle, len(b.Values))
+	for i, v := range b.Values {
+		x0 := margin + int(float64(i)*step) + gap
+		x1 := margin + int(float64(i+1)*step) - gap
+		y0 := height - margin - v*plotHeight/b.max()
+		rects[i] = image.Rect(x0, y0, max(x1, x0+1), height-margin)
+	}
+	return rects
+}
+
+// gridY returns the height in image coordinates of the i-th grid line and the
+// value it marks.
+func (b Bar) gridY(i int) (int, int) {
+	plotHeight := height - 2*margin
+	return height - margin - i*plotHeight/gridLines, b.max() * i / gridLines
+}
+
+// PNG draws b as a PNG image to w. The standard library has no fonts, so
+// unlike SVG it has only the bars and the grid, without any text.
+func (b Bar) PNG(w io.Writer) error {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{background}, image.Point{}, draw.Src)
+
+	for i := 1; i <= gridLines; i++ {
+		y, _ := b.gridY(i)
+		draw.Draw(img, image.Rect(margin, y, width-margin, y+1), &image.Uniform{grid}, image.Point{}, draw.Src)
+	}
+	for _, r := range b.bars() {
+		draw.Draw(img, r, &image.Uniform{barColor}, image.Point{}, draw.Src)
+	}
+	draw.Draw(img, image.Rect(margin, height-margin, width-margin, height-margin+1), &image.Uniform{axis}, image.Point{}, draw.Src)
+
+	err := png.Encode(w, img)
+	if err != nil {
+		return fmt.Errorf("encoding PNG: %w", err)
+	}
+	return nil
+}
+
+// SVG draws b as an SVG image to w, with its title, the name of its series and
+// the labels of both axes.
+func (b Bar) SVG(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n", width, height, width, height)
+	fmt.Fprintf(&sb, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hex(background))
+	fmt.Fprintf(&sb, `<text x="%d" y="%d" font-size="16" font-weight="bold">%s</text>`+"\n", margin, margin/2, html.EscapeString(b.Title))
+	fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="end" fill="%s">%s</text>`+"\n", width-margin, margin/2, hex(barColor), html.EscapeString(b.Series))
+
+	for i := 0; i <= gridLines; i++ {
+		y, value := b.gridY(i)
+		if i > 0 {
+			fmt.Fprintf(&sb, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s"/>`+"\n", margin, y, width-margin, y, hex(grid))
+		}
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="end" fill="%s">%d</text>`+"\n", margin-6, y+4, hex(axis), value)
+	}
+
+	// only label some of the bars when there are too many to fit their labels
+	every := max(1, len(b.Labels)/10)
+	for i, r := range b.bars() {
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n", r.Min.X, r.Min.Y, r.Dx(), r.Dy(), hex(barColor))
+		if i < len(b.Labels) && i%every == 0 {
+			fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="middle" fill="%s">%s</text>`+"\n", (r.Min.X+r.Max.X)/2, height-margin+18, hex(axis), html.EscapeString(b.Labels[i]))
+		}
+	}
+	fmt.Fprintf(&sb, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s"/>`+"\n", margin, height-margin, width-margin, height-margin, hex(axis))
+	sb.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	if err != nil {
+		return fmt.Errorf("writing SVG: %w", err)
+	}
+	return nil
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -16,8 +16,14 @@ const (
 )
 
 type Config struct {
-	AppName  string
+	AppName string
+	// File, when set, is the config file read and written instead of the
+	// user config.
+	File     string
 	gapScope *gap.Scope
+	// files holds the values of the config files as they're written, with
+	// environment variables unexpanded, which is what Write writes back
+	files *viper.Viper
 	*viper.Viper
 }
 
@@ -35,19 +41,80 @@ func NewConfig(appName string) *Config {
 	return &Config{
 		AppName:  appName,
 		Viper:    viperCfg,
+		files:    viper.New(),
 		gapScope: gap.NewScope(gap.User, appName),
 	}
 }
 
+// mergeFile merges the config file at path, with environment variables such as
+// $HOME or ${PROJECT} expanded in its string values. Only values of config
+// files are expanded, flags and environment variables are used as they are.
+func (c *Config) mergeFile(path string) error {
+	file := viper.New()
+	file.SetConfigFile(path)
+	err := file.ReadInConfig()
+	if err != nil {
+		return err
+	}
+
+	settings := file.AllSettings()
+	err = c.files.MergeConfigMap(settings)
+	if err != nil {
+		return err
+	}
+	return c.MergeConfigMap(expandEnv(settings).(map[string]any))
+}
+
+// expandEnv returns v with environment variables expanded in its strings,
+// and in the strings of its maps and slices.
+func expandEnv(v any) any {
+	switch v := v.(type) {
+	case string:
+		return os.ExpandEnv(v)
+	case map[string]any:
+		expanded := make(map[string]any, len(v))
+		for k, e := range v {
+			expanded[k] = expandEnv(e)
+		}
+		return expanded
+	case []any:
+		expanded := make([]any, len(v))
+		for i, e := range v {
+			expanded[i] = expandEnv(e)
+		}
+		return expanded
+	}
+	return v
+}
+
+// Set sets the value of key for this run, and in the config written by
+// Write.
+func (c *Config) Set(key string, value any) {
+	c.Viper.Set(key, value)
+	c.files.Set(key, value)
+}
+
+// Read merges the user config files, or only File when it's set. A missing
+// File is an error wrapping fs.ErrNotExist.
 func (c *Config) Read() error {
+	if c.File != "" {
+		if _, err := os.Stat(c.File); err != nil {
+			return fmt.Errorf("reading config: %w", err)
+		}
+		err := c.mergeFile(c.File)
+		if err != nil {
+			return fmt.Errorf("merging config from '%s': %w", c.File, err)
+		}
+		return nil
+	}
+
 	paths, err := c.gapScope.LookupConfig(configName + "." + configType)
 	if err != nil {
 		return fmt.Errorf("getting config path: %w", err)
 	}
 
 	for _, path := range slices.Backward(paths) {
-		c.SetConfigFile(path)
-		err := c.MergeInConfig()
+		err := c.mergeFile(path)
 		if err != nil {
 			return fmt.Errorf("merging config from '%s': %w", path, err)
 		}
@@ -56,36 +123,90 @@ func (c *Config) Read() error {
 	return nil
 }
 
-func (c *Config) Write() error {
-	paths, err := c.gapScope.LookupConfig(configName + "." + configType)
+// ReadProjectConfig merges the project config file, <app name>.yaml or
+// <app name>.yml, found in the current directory or the closest of its
+// parents. Its values take precedence over the user config, but not over
+// flags or environment variables.
+func (c *Config) ReadProjectConfig() error {
+	dir, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("getting config path: %w", err)
+		return fmt.Errorf("getting current directory: %w", err)
 	}
 
-	if len(paths) == 0 {
-		configDirs, err := c.gapScope.ConfigDirs()
-		if err != nil {
-			return fmt.Errorf("getting config dir alternatives to create a new config file: %w", err)
+	for {
+		for _, ext := range []string{"yaml", "yml"} {
+			path := filepath.Join(dir, c.AppName+"."+ext)
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+
+			err = c.mergeFile(path)
+			if err != nil {
+				return fmt.Errorf("merging project config from '%s': %w", path, err)
+			}
+			return nil
 		}
-		configDir := configDirs[0]
-		err = os.MkdirAll(configDir, 0750)
-		if err != nil {
-			return fmt.Errorf("creating config dir: %w", err)
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
 		}
+		dir = parent
+	}
+}
+
+// APIKey returns the Deepgram API key. In order of precedence it comes from
+// the api-key flag, the DEEPGRAM_API_KEY environment variable, or the apikey
+// config key, which can also be set with DGRAM_APIKEY.
+func (c *Config) APIKey() string {
+	if key := c.GetString("api-key"); key != "" {
+		return key
+	}
+	if key := os.Getenv("DEEPGRAM_API_KEY"); key != "" {
+		return key
+	}
+	return c.GetString("apikey")
+}
 
-		configPath := filepath.Join(configDir, configName+"."+configType)
-		c.SetConfigFile(configPath)
-	} else {
-		c.SetConfigFile(paths[0])
+func (c *Config) Write() error {
+	configPath, err := c.Path()
+	if err != nil {
+		return err
 	}
 
-	err = c.WriteConfig()
+	err = os.MkdirAll(filepath.Dir(configPath), 0750)
+	if err != nil {
+		return fmt.Errorf("creating config dir: %w", err)
+	}
+	err = c.files.WriteConfigAs(configPath)
 	if err != nil {
 		return fmt.Errorf("writing config: %w", err)
 	}
 	return nil
 }
 
+// Path returns File when it's set, otherwise the path of the user config
+// file, or the path where it should be created when there's none yet.
+func (c *Config) Path() (string, error) {
+	if c.File != "" {
+		return c.File, nil
+	}
+
+	paths, err := c.gapScope.LookupConfig(configName + "." + configType)
+	if err != nil {
+		return "", fmt.Errorf("getting config path: %w", err)
+	}
+	if len(paths) > 0 {
+		return paths[0], nil
+	}
+
+	configDirs, err := c.gapScope.ConfigDirs()
+	if err != nil {
+		return "", fmt.Errorf("getting config dir alternatives to create a new config file: %w", err)
+	}
+	return filepath.Join(configDirs[0], configName+"."+configType), nil
+}
+
 func (c *Config) DataDir() (string, error) {
 	dataPaths, err := c.gapScope.DataDirs()
 	if err != nil {
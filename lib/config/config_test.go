@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestExpandEnvOnlyInConfigFiles(t *testing.T) {
+	t.Setenv("DGRAM_TEST_DIR", "/media/talks")
+	t.Setenv("x", "expanded")
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	data := "output-dir: ${DGRAM_TEST_DIR}/out\nformat:\n  - $x\nspeaker-names:\n  \"0\": $x\n"
+	err := os.WriteFile(path, []byte(data), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewConfig("dgram")
+	c.File = path
+	err = c.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("replace-file", "", "")
+	flags.String("name-template", "", "")
+	err = flags.Parse([]string{"--replace-file", "$x", "--name-template", "{base}_$5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.BindPFlags(flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := c.GetString("output-dir"), "/media/talks/out"; got != want {
+		t.Errorf("output-dir from the config file = %q, want %q", got, want)
+	}
+	if got := c.GetStringSlice("format"); len(got) != 1 || got[0] != "expanded" {
+		t.Errorf("format from the config file = %q, want [expanded]", got)
+	}
+	if got := c.GetStringMapString("speaker-names")["0"]; got != "expanded" {
+		t.Errorf("speaker-names from the config file = %q, want %q", got, "expanded")
+	}
+	if got, want := c.GetString("replace-file"), "$x"; got != want {
+		t.Errorf("replace-file flag = %q, want %q", got, want)
+	}
+	if got, want := c.GetString("name-template"), "{base}_$5"; got != want {
+		t.Errorf("name-template flag = %q, want %q", got, want)
+	}
+}
+
+func TestWriteKeepsVariables(t *testing.T) {
+	t.Setenv("DGRAM_TEST_DIR", "/media/talks")
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	err := os.WriteFile(path, []byte("output-dir: $DGRAM_TEST_DIR\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewConfig("dgram")
+	c.File = path
+	err = c.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Set("model", "nova-3")
+	err = c.Write()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	written := NewConfig("dgram")
+	written.File = path
+	err = written.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := written.files.GetString("output-dir"); got != "$DGRAM_TEST_DIR" {
+		t.Errorf("written output-dir = %q, want %q", got, "$DGRAM_TEST_DIR")
+	}
+	if got := written.GetString("model"); got != "nova-3" {
+		t.Errorf("written model = %q, want %q", got, "nova-3")
+	}
+}
@@ -1,8 +1,15 @@
 package fsys
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 )
 
 func FileExists(file string) bool {
@@ -66,3 +73,96 @@ func IsBeingDownloaded(filePath string) bool {
 
 	return false
 }
+
+// FilesFromGlobs returns the paths matching any of the given glob patterns.
+func FilesFromGlobs(globs []string) ([]string, error) {
+	files := make([]string, 0, 4)
+	for _, glob := range globs {
+		matches, err := filepath.Glob(glob)
+		if err != nil {
+			return nil, fmt.Errorf("globbing files with glob %q: %w", glob, err)
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// ExpandDirs replaces the directories in paths with the files in them, and in
+// all of their subdirectories, for which keep returns true. Hidden
+// directories, and the ones named like one of skip, like the ones dgram writes
+// its outputs to, are skipped.
+func ExpandDirs(paths []string, keep func(path string) bool, skip ...string) ([]string, error) {
+	files := make([]string, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if p != path && (strings.HasPrefix(d.Name(), ".") || slices.Contains(skip, d.Name())) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if keep(p) {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("walking directory %q: %w", path, err)
+		}
+	}
+	return files, nil
+}
+
+// WriteFileAtomic writes data to a temporary file next to name and then renames
+// it to name, so readers never observe a partially written file.
+func WriteFileAtomic(name string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating temporary file: %w", err)
+	}
+	tmp := f.Name()
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, name)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// HashFile returns the hex encoded SHA-256 of the contents of file.
+func HashFile(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
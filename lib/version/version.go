@@ -0,0 +1,41 @@
+// Package version reports the version of dgram and of the modules it was
+// built with.
+package version
+
+import "runtime/debug"
+
+// Version is the dgram version. It can be set at build time with
+// -ldflags "-X dgram/lib/version.Version=v1.2.3"; otherwise the version
+// recorded by the Go toolchain is used.
+var Version = ""
+
+// Dgram returns the version of dgram.
+func Dgram() string {
+	if Version != "" {
+		return Version
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "" {
+		return "unknown"
+	}
+	return info.Main.Version
+}
+
+// Module returns the version of the dependency with the given module path.
+func Module(path string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path == path {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "unknown"
+}